@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// aliases returns the aliases of the app and the ones defined in the alias
+// section of its configuration. The latter take precedence.
+func (a *App) aliases(ctx context.Context) map[string]string {
+	set := make(map[string]string)
+	for k, v := range a.Aliases {
+		set[k] = v
+	}
+	cfg := ConfigFrom(ctx)
+	for _, k := range cfg.Keys() {
+		if name := strings.TrimPrefix(k, "alias."); name != k {
+			set[name] = cfg.Get(k)
+		}
+	}
+	return set
+}
+
+// expand replaces the first argument by the words of its alias, if any, until
+// the first argument is a command. Aliases can not redefine commands.
+func (a *App) expand(ctx context.Context, args []string) ([]string, error) {
+	var (
+		set  = a.aliases(ctx)
+		seen = make(map[string]bool)
+	)
+	for len(args) > 0 && a.lookup(args[0]) == nil {
+		str, ok := set[args[0]]
+		if !ok {
+			break
+		}
+		if seen[args[0]] {
+			return nil, fmt.Errorf("%s: recursive alias", args[0])
+		}
+		seen[args[0]] = true
+
+		words, err := Split(str)
+		if err != nil {
+			return nil, fmt.Errorf("alias %s: %w", args[0], err)
+		}
+		if len(words) == 0 {
+			return nil, fmt.Errorf("alias %s: empty alias", args[0])
+		}
+		args = append(words, args[1:]...)
+	}
+	return args, nil
+}
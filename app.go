@@ -0,0 +1,588 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// NoArgsPolicy selects what an App does when it is run without arguments.
+type NoArgsPolicy int
+
+const (
+	// NoArgsUsage prints the help of the app.
+	NoArgsUsage NoArgsPolicy = iota
+	// NoArgsDefault runs the default command.
+	NoArgsDefault
+	// NoArgsShell starts an interactive shell, see Shell.
+	NoArgsShell
+)
+
+type App struct {
+	Commands []*Command
+	Usage    func()
+	Template string
+	Prompt   string
+	// Aliases maps a name to the command line it stands for, like
+	// "st": "status --short". Aliases are also read from the alias section of
+	// the configuration.
+	Aliases map[string]string
+	// History, when set, records the commands run to improve the suggestions
+	// given for unknown commands.
+	History *History
+	// Config is the name used to find the configuration files of the app
+	// with ConfigFiles. The files given with --config are loaded after them.
+	// Under hooks.<command>.post, users can set a shell command run after
+	// each success of the command, receiving the records it printed, in JSON,
+	// on its standard input. Hooks are only read from the configuration file
+	// of the user.
+	Config string
+	// EnvPrefix, when set, lets the environment give the flags of the commands
+	// their values: the variable PREFIX_NAME, NAME being the name of the flag
+	// in upper case with its dashes replaced by underscores, is used when the
+	// flag is not given on the command line. It overrides the configuration.
+	EnvPrefix string
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// ExitPolicy selects the exit code when a command fails with Errors.
+	ExitPolicy ExitPolicy
+	// NoArgs selects what Run does without arguments.
+	NoArgs NoArgsPolicy
+	// Version selects how --version and the version command behave.
+	Version VersionPolicy
+	// Misuse selects when the mistakes in the definition of the app are
+	// reported. See Validate.
+	Misuse MisusePolicy
+	// Info overrides the package variables Version, BuildTime, CompileWith
+	// and CompileHost for this app. Its fields are used when not empty.
+	// Info.Name replaces the name of the executable in the messages, the
+	// help and the version, for programs run through links or wrappers.
+	Info VersionInfo
+	// Warnings are the warnings the app can print with Warn. They are shown
+	// by the global --list-warnings flag.
+	Warnings []WarningInfo
+	// Updates, when set, is checked once a day in the background for a newer
+	// version of the app. Users are told about it on the standard error. The
+	// check is disabled by the NO_UPDATE_NOTIFIER environment variable.
+	Updates VersionSource
+	// Policy, when set, can disable commands of some versions of the app,
+	// for instance to stop a broken release from doing harm. Commands run
+	// as usual when the policy cannot be obtained.
+	Policy PolicySource
+	// Schema describes the keys of the configuration. See ConfigCommand.
+	Schema ConfigSchema
+	// Keyring keeps the key encrypting the sensitive values of the
+	// configuration. By default, it is the keyring of the system.
+	Keyring CredentialStore
+	// Clock is the source of time of the commands, SystemClock by default.
+	// See ClockFrom.
+	Clock Clock
+	// Profiling enables the hidden --cpuprofile, --memprofile and --trace
+	// flags, writing the corresponding profiles of the command to the given
+	// files.
+	Profiling bool
+
+	// Interrupt makes the first interrupt or termination signal cancel the
+	// context of the running command, for it to stop cleanly, instead of
+	// exiting the process. A second signal still exits it.
+	Interrupt bool
+
+	// Main, when set, is the only command of the app. The arguments given to
+	// Run do not start with the name of a command and go directly to Main,
+	// except for the global flags not defined by Main.
+	Main *Command
+
+	// Interactive prevents the help of the app and of its commands to exit the
+	// process. Commands asked for help return flag.ErrHelp instead.
+	Interactive bool
+
+	mu      sync.Mutex
+	running int
+	exits   []func()
+}
+
+// OnExit registers fn to be run when Run returns, whatever the result of the
+// command, or before the process exits because of a panic or a signal. The
+// functions run once, the most recent first.
+func (a *App) OnExit(fn func()) {
+	var once sync.Once
+	run := func() {
+		once.Do(fn)
+	}
+	remove := atExit(run)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.exits = append(a.exits, func() {
+		remove()
+		run()
+	})
+}
+
+func (a *App) enter() func() {
+	a.mu.Lock()
+	a.running++
+	a.mu.Unlock()
+	return func() {
+		a.mu.Lock()
+		var list []func()
+		if a.running--; a.running == 0 {
+			list, a.exits = a.exits, nil
+		}
+		a.mu.Unlock()
+		for i := len(list) - 1; i >= 0; i-- {
+			list[i]()
+		}
+	}
+}
+
+// name returns the name of the program shown to the users.
+func (a *App) name() string {
+	if a.Info.Name != "" {
+		return a.Info.Name
+	}
+	return programName()
+}
+
+// nested reports whether Run is called by a command of the app, like Shell.
+func (a *App) nested() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.running > 1
+}
+
+// Execute runs the app with the given arguments and reports the error, if
+// any, on the standard error of the app. It returns the exit code that the
+// process should use.
+func (a *App) Execute(args []string) int {
+	err := a.Run(args)
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return ExitCode(err)
+	}
+	return report(a.stderr(), err, a.Commands, a.ExitPolicy)
+}
+
+func (a *App) Run(args []string) error {
+	defer a.enter()()
+	if a.Misuse == MisusePanic {
+		if err := a.Validate(); err != nil {
+			panic(err)
+		}
+	}
+	if a.Main != nil {
+		return a.runMain(args)
+	}
+	var (
+		fset = flag.NewFlagSet("", flag.ContinueOnError)
+		opts globals
+	)
+	fset.Usage = a.Usage
+	if a.Interactive {
+		// the usage of the app can exit the process
+		fset.Usage = func() { a.help(a.stderr(), nil) }
+	}
+	fset.SetOutput(io.Discard)
+	opts.register(fset)
+	if a.Profiling {
+		opts.profiles.register(fset)
+	}
+	if a.Version.Combine && len(args) > 0 && isVersionFlag(args[0]) {
+		ctx, err := a.context(&opts)
+		if err != nil {
+			return err
+		}
+		return a.version(ctx, args[1:])
+	}
+	// the flags of the default command are left to it, even when they have
+	// the name of a global flag
+	var defArgs []string
+	if def := a.defaultCommand(); def != nil {
+		def.prepare()
+		globals, rest := splitGlobals(fset, &def.Flag, args)
+		if len(rest) > 0 && rest[0] != "-" && rest[0] != "--" && strings.HasPrefix(rest[0], "-") {
+			args, defArgs = globals, rest
+		}
+	}
+	err := fset.Parse(args)
+	ctx, cerr := a.context(&opts)
+	if cerr != nil {
+		return cerr
+	}
+	stop, cerr := opts.profiles.start()
+	if cerr != nil {
+		return cerr
+	}
+	defer stop()
+	defer a.checkUpdate(ctx)()
+	if err != nil {
+		if !strings.HasPrefix(err.Error(), "flag provided but not defined") {
+			return err
+		}
+		return a.tryDefault(ctx, args)
+	}
+	if defArgs != nil {
+		return a.tryDefault(ctx, defArgs)
+	}
+
+	if opts.version {
+		printVersion(ctx, a.stdout())
+		return nil
+	}
+	if opts.listWarnings {
+		a.listWarnings(ctx, a.stdout())
+		return nil
+	}
+	if fset.Arg(0) == "version" && a.lookup("version") == nil {
+		return a.version(ctx, fset.Args()[1:])
+	}
+	if fset.Arg(0) == completeCmd {
+		return a.complete(ctx, a.stdout(), fset.Args()[1:])
+	}
+	if fset.Arg(0) == selftestCmd && a.lookup(selftestCmd) == nil {
+		return a.selftest(ctx, a.stdout(), fset.Args()[1:])
+	}
+	if fset.Arg(0) == "completion" && a.lookup("completion") == nil {
+		// the scripts keep the line endings of their shell unless asked
+		if EOL(ctx) == NativeEOL {
+			return a.completion(a.stdout(), fset.Args()[1:])
+		}
+		var buf bytes.Buffer
+		if err := a.completion(&buf, fset.Args()[1:]); err != nil {
+			return err
+		}
+		_, err := a.stdout().Write(EOL(ctx).Convert(buf.Bytes()))
+		return err
+	}
+	if fset.Arg(0) == "help" && (fset.Arg(1) == "-json" || fset.Arg(1) == "--json") {
+		return a.describe(a.stdout())
+	}
+	if fset.Arg(0) == "help" && (fset.Arg(1) == "-json-schema" || fset.Arg(1) == "--json-schema") {
+		return writeSchema(a.stdout(), SpecSchema())
+	}
+	if fset.NArg() == 0 {
+		switch a.NoArgs {
+		case NoArgsDefault:
+			return a.tryDefault(ctx, nil)
+		case NoArgsShell:
+			if !a.Interactive {
+				return Shell(a)
+			}
+		}
+	}
+	if fset.NArg() == 0 || fset.Arg(0) == "help" {
+		a.usage(fset.Args())
+		return nil
+	}
+	args, err = a.expand(ctx, fset.Args())
+	if err != nil {
+		return err
+	}
+	if c := a.lookup(args[0]); c != nil {
+		if a.History != nil {
+			if err := a.History.Record(c.String()); err != nil {
+				Warn(ctx, a.stderr(), HistoryFailedWarning, "%s not recorded in the history: %s", c, err)
+			}
+		}
+		return a.execute(ctx, c, args[1:])
+	}
+	return a.suggest(args[0])
+}
+
+func (a *App) suggest(cmd string) error {
+	err := SuggestError{
+		Cmd:  cmd,
+		Prog: a.name(),
+	}
+	if a.History != nil {
+		err.Counts, _ = a.History.Counts()
+	}
+	return err
+}
+
+func (a *App) runMain(args []string) error {
+	var (
+		fset = flag.NewFlagSet("", flag.ContinueOnError)
+		opts globals
+	)
+	fset.SetOutput(io.Discard)
+	opts.register(fset)
+	if a.Profiling {
+		opts.profiles.register(fset)
+	}
+	a.Main.prepare()
+
+	args, rest := splitGlobals(fset, &a.Main.Flag, args)
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	ctx, err := a.context(&opts)
+	if err != nil {
+		return err
+	}
+	stop, err := opts.profiles.start()
+	if err != nil {
+		return err
+	}
+	defer stop()
+	defer a.checkUpdate(ctx)()
+	if opts.version {
+		printVersion(ctx, a.stdout())
+		return nil
+	}
+	if opts.listWarnings {
+		a.listWarnings(ctx, a.stdout())
+		return nil
+	}
+	return a.execute(ctx, a.Main, rest)
+}
+
+// splitGlobals separates the flags of args defined in globals but not in set
+// from the other arguments. It stops at the first argument that is not a
+// flag.
+func splitGlobals(globals, set *flag.FlagSet, args []string) ([]string, []string) {
+	var g, rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-" || !strings.HasPrefix(arg, "-") || arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		name := strings.TrimLeft(arg, "-")
+		value := strings.Contains(name, "=")
+		if value {
+			name = name[:strings.Index(name, "=")]
+		}
+		f := set.Lookup(name)
+		if f == nil {
+			if f = globals.Lookup(name); f != nil {
+				g = append(g, arg)
+				if !value && !isBoolFlag(f) && i+1 < len(args) {
+					i++
+					g = append(g, args[i])
+				}
+				continue
+			}
+		}
+		rest = append(rest, arg)
+		if f != nil && !value && !isBoolFlag(f) && i+1 < len(args) {
+			i++
+			rest = append(rest, args[i])
+		}
+	}
+	return g, rest
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func (a *App) usage(args []string) {
+	if a.Usage != nil && !a.Interactive {
+		a.Usage()
+		return
+	}
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	a.help(a.stderr(), args)
+}
+
+func (a *App) lookup(name string) *Command {
+	for _, c := range a.Commands {
+		if !c.Runnable() {
+			continue
+		}
+		if c.String() == name {
+			return c
+		}
+		for _, n := range c.Alias {
+			if n == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+func (a *App) tryDefault(ctx context.Context, args []string) error {
+	if c := a.defaultCommand(); c != nil {
+		return a.execute(ctx, c, args)
+	}
+	return fmt.Errorf("no sub-command given!")
+}
+
+func (a *App) defaultCommand() *Command {
+	for _, c := range a.Commands {
+		if c.Default && c.Runnable() {
+			return c
+		}
+	}
+	return nil
+}
+
+func (a *App) execute(ctx context.Context, c *Command, args []string) error {
+	return a.executeTo(ctx, c, args, a.stdout(), a.stderr())
+}
+
+// executeTo runs c with stdout and stderr as its outputs.
+func (a *App) executeTo(ctx context.Context, c *Command, args []string, stdout, stderr io.Writer) error {
+	usage := c.Help
+	if a.Interactive {
+		usage = func() { c.printHelp(ctx, stderr) }
+	}
+	var (
+		hook = postHook(ctx, c)
+		rec  *recorder
+	)
+	if hook != "" {
+		rec = &recorder{}
+		ctx = context.WithValue(ctx, hookKey{}, rec)
+	}
+	c.parent = ctx
+	c.stdin, c.stdout, c.stderr = a.Stdin, stdout, stderr
+	c.Flag.SetOutput(stderr)
+	if err := a.checkPolicy(ctx, c); err != nil {
+		return err
+	}
+	err := c.execute(args, usage)
+	if err == nil && hook != "" {
+		a.runHook(ctx, c, hook, rec.records)
+	}
+	if seed, ok := usedSeed(ctx); ok && err != nil {
+		Message(ctx, stderr, Info, "random seed: %d (use --seed=%d to reproduce)", seed, seed)
+	}
+	return err
+}
+
+// globals are the flags accepted by every app before the name of the command.
+type globals struct {
+	version       bool
+	deterministic bool
+	plain         bool
+	offline       bool
+	dryRun        bool
+	progress      ProgressMode
+	theme         Theme
+	config        ConfigPaths
+	suppress      Patterns
+	listWarnings  bool
+	seed          seedValue
+	eol           LineEnding
+	verbose       Counter
+	profiles      profiles
+}
+
+func (g *globals) register(fset *flag.FlagSet) {
+	g.deterministic = os.Getenv("SOURCE_DATE_EPOCH") != ""
+	g.plain = plainTerminal()
+	fset.BoolVar(&g.version, "v", false, "")
+	fset.BoolVar(&g.version, "version", false, "")
+	fset.BoolVar(&g.deterministic, "deterministic", g.deterministic, "")
+	fset.BoolVar(&g.plain, "plain", g.plain, "")
+	fset.BoolVar(&g.offline, "offline", false, "")
+	fset.BoolVar(&g.dryRun, "dry-run", false, "")
+	fset.Var(&g.progress, "progress", "")
+	fset.Var(themeValue{&g.theme}, "theme", "")
+	fset.Var(&g.config, "config", "")
+	fset.Var(&g.suppress, "suppress-warn", "")
+	fset.BoolVar(&g.listWarnings, "list-warnings", false, "")
+	fset.Var(&g.seed, "seed", "")
+	fset.Var(&g.eol, "eol", "")
+	fset.Var(&g.verbose, "verbose", "")
+}
+
+func (g *globals) context() context.Context {
+	ctx := withProgress(context.Background(), g.progress)
+	ctx = withTheme(ctx, g.theme)
+	ctx = withPlain(ctx, g.plain)
+	ctx = withEOL(ctx, g.eol)
+	ctx = withVerbosity(ctx, g.verbose.Int())
+	if g.deterministic {
+		ctx = withDeterministic(ctx)
+	}
+	if g.offline {
+		ctx = withOffline(ctx)
+	}
+	return ctx
+}
+
+func (a *App) context(opts *globals) (context.Context, error) {
+	var (
+		ctx   = withApp(withClock(opts.context(), a.Clock), a)
+		files []string
+	)
+	switch {
+	case opts.seed.set:
+		ctx = withSeed(ctx, opts.seed.seed)
+	case opts.deterministic:
+		ctx = withSeed(ctx, 0)
+	default:
+		ctx = withSeed(ctx, ClockFrom(ctx).Now().UnixNano())
+	}
+	if opts.dryRun {
+		ctx = withDryRun(ctx, a.stderr())
+	}
+	if a.Interrupt && !a.nested() {
+		var stop func()
+		ctx, stop = watchSignals(ctx)
+		a.OnExit(stop)
+	}
+	for _, file := range opts.config {
+		if _, err := os.Stat(file); err != nil {
+			return ctx, err
+		}
+	}
+	if a.Config != "" {
+		files = ConfigFiles(a.Config)
+	}
+	files = append(files, opts.config...)
+	if len(files) > 0 {
+		cfg, err := LoadConfig(files...)
+		if err != nil {
+			return ctx, err
+		}
+		if err := cfg.unseal(a.keyring()); err != nil {
+			return ctx, err
+		}
+		ctx = withConfig(ctx, cfg)
+	}
+	return a.withSuppressed(ctx, opts.suppress)
+}
+
+func (a *App) keyring() CredentialStore {
+	if a.Keyring == nil {
+		return KeyringStore{Name: a.Config}
+	}
+	return a.Keyring
+}
+
+func (a *App) stdin() io.Reader {
+	if a.Stdin == nil {
+		return os.Stdin
+	}
+	return a.Stdin
+}
+
+func (a *App) stdout() io.Writer {
+	if a.Stdout == nil {
+		return os.Stdout
+	}
+	return a.Stdout
+}
+
+func (a *App) stderr() io.Writer {
+	if a.Stderr == nil {
+		return os.Stderr
+	}
+	return a.Stderr
+}
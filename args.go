@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ArgType converts the raw value of a positional argument.
+type ArgType struct {
+	Name  string
+	Parse func(string) (interface{}, error)
+}
+
+var (
+	String = ArgType{
+		Name: "string",
+		Parse: func(str string) (interface{}, error) {
+			return str, nil
+		},
+	}
+	Int = ArgType{
+		Name: "int",
+		Parse: func(str string) (interface{}, error) {
+			return strconv.Atoi(str)
+		},
+	}
+	Float = ArgType{
+		Name: "float",
+		Parse: func(str string) (interface{}, error) {
+			return strconv.ParseFloat(str, 64)
+		},
+	}
+	Bool = ArgType{
+		Name: "bool",
+		Parse: func(str string) (interface{}, error) {
+			return strconv.ParseBool(str)
+		},
+	}
+	Duration = ArgType{
+		Name: "duration",
+		Parse: func(str string) (interface{}, error) {
+			return time.ParseDuration(str)
+		},
+	}
+	// Input is an existing file, - for the standard input or a http(s) URL,
+	// opened as a *File.
+	Input = ArgType{
+		Name: "file",
+		Parse: func(str string) (interface{}, error) {
+			f := File{Open: true, URL: true}
+			return &f, f.Set(str)
+		},
+	}
+)
+
+// Choice returns an ArgType only accepting one of the given values.
+func Choice(values ...string) ArgType {
+	return ArgType{
+		Name: strings.Join(values, "|"),
+		Parse: func(str string) (interface{}, error) {
+			for _, v := range values {
+				if v == str {
+					return str, nil
+				}
+			}
+			if s := suggestChoice(str, values); s != "" {
+				return nil, fmt.Errorf("did you mean %q? (expected %s)", s, joinChoices(values))
+			}
+			return nil, fmt.Errorf("expected %s", joinChoices(values))
+		},
+	}
+}
+
+// Argument describes a positional argument of a command. Optional arguments
+// should follow the required ones and only the last argument can be variadic.
+type Argument struct {
+	Name     string
+	Type     ArgType
+	Optional bool
+	Variadic bool
+}
+
+func Arg(name string, typ ArgType) Argument {
+	return Argument{
+		Name: name,
+		Type: typ,
+	}
+}
+
+func (a Argument) Opt() Argument {
+	a.Optional = true
+	return a
+}
+
+func (a Argument) Many() Argument {
+	a.Variadic = true
+	return a
+}
+
+func (a Argument) String() string {
+	str := "<" + a.Name + ">"
+	if a.Variadic {
+		str += "..."
+	}
+	if a.Optional {
+		str = "[" + str + "]"
+	}
+	return str
+}
+
+// Value returns the value of the positional argument name once converted
+// according to its type. The value of a variadic argument is a []interface{}.
+// Value returns nil for unknown arguments and omitted optional arguments.
+func (c *Command) Value(name string) interface{} {
+	return c.values[name]
+}
+
+func (c *Command) parseArgs(args []string) error {
+	if len(c.Args) == 0 {
+		return nil
+	}
+	if err := checkArgs(c.Args); err != nil {
+		return err
+	}
+	c.values = make(map[string]interface{})
+	for i, a := range c.Args {
+		if i >= len(args) {
+			if !a.Optional {
+				return fmt.Errorf("missing argument %s", a)
+			}
+			break
+		}
+		if !a.Variadic {
+			v, err := parseArg(c.Context(), a, args[i])
+			if err != nil {
+				return err
+			}
+			c.values[a.Name] = v
+			continue
+		}
+		var list []interface{}
+		for _, str := range args[i:] {
+			v, err := parseArg(c.Context(), a, str)
+			if err != nil {
+				return err
+			}
+			list = append(list, v)
+		}
+		c.values[a.Name] = list
+		return nil
+	}
+	if len(args) > len(c.Args) {
+		return fmt.Errorf("too many arguments (expected %d, got %d)", len(c.Args), len(args))
+	}
+	return nil
+}
+
+// parseArg converts str to the type of a. The files it gives are downloaded
+// with ctx, like the File flags of the command.
+func parseArg(ctx context.Context, a Argument, str string) (interface{}, error) {
+	typ := a.Type
+	if typ.Parse == nil {
+		typ = String
+	}
+	v, err := typ.Parse(str)
+	if err != nil {
+		if _, ok := err.(*strconv.NumError); ok {
+			err = fmt.Errorf("expected %s", typ.Name)
+		}
+		return nil, fmt.Errorf("%s: invalid value %q: %w", a.Name, str, err)
+	}
+	if f, ok := v.(*File); ok {
+		f.ctx = ctx
+	}
+	return v, nil
+}
+
+func checkArgs(args []Argument) error {
+	var optional bool
+	for i, a := range args {
+		if a.Variadic && i < len(args)-1 {
+			return fmt.Errorf("%s: only the last argument can be variadic", a.Name)
+		}
+		if optional && !a.Optional {
+			return fmt.Errorf("%s: required argument after optional argument", a.Name)
+		}
+		optional = optional || a.Optional
+	}
+	return nil
+}
+
+func argsUsage(args []Argument) string {
+	list := make([]string, len(args))
+	for i, a := range args {
+		list[i] = a.String()
+	}
+	return strings.Join(list, " ")
+}
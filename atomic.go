@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// WriteFileAtomic writes data to file with the given permissions, so that
+// file either keeps its previous content or has all of data, even if the
+// program or the system crashes. Data is written and synced to a temporary
+// file of the same directory, then renamed to file. When file is a symbolic
+// link, its target is written, and the link is left as is.
+func WriteFileAtomic(file string, data []byte, mode os.FileMode) error {
+	file, err := resolvePath(file)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(file)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	if err := replaceFile(tmp.Name(), file); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// WriteFileBackup is like WriteFileAtomic but keeps the previous content of
+// file, if any, in file~, file being the target of the link when it is a
+// symbolic link.
+func WriteFileBackup(file string, data []byte, mode os.FileMode) error {
+	file, err := resolvePath(file)
+	if err != nil {
+		return err
+	}
+	old, err := os.ReadFile(file)
+	if err == nil {
+		i, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		if err := WriteFileAtomic(file+"~", old, i.Mode().Perm()); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return WriteFileAtomic(file, data, mode)
+}
+
+// replaceFile renames tmp to file. On Windows, the rename fails while file is
+// opened by another process, like an antivirus or an indexer, so it is tried
+// again for a short time.
+func replaceFile(tmp, file string) error {
+	err := os.Rename(tmp, file)
+	if err == nil || runtime.GOOS != "windows" {
+		return err
+	}
+	for i := 0; i < 10 && err != nil; i++ {
+		time.Sleep(time.Duration(i+1) * 10 * time.Millisecond)
+		err = os.Rename(tmp, file)
+	}
+	return err
+}
+
+// syncDir makes the rename of a file in dir durable. Errors are ignored as
+// some systems do not support it.
+func syncDir(dir string) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteFileLink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need privileges on windows")
+	}
+	var (
+		dir    = t.TempDir()
+		target = filepath.Join(dir, "target")
+		link   = filepath.Join(dir, "link")
+	)
+	if err := os.WriteFile(target, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("target", link); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileBackup(link, []byte("new"), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if i, err := os.Lstat(link); err != nil || i.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("%s should still be a symbolic link", link)
+	}
+	for file, want := range map[string]string{target: "new", target + "~": "old"} {
+		got, err := os.ReadFile(file)
+		if err != nil || string(got) != want {
+			t.Errorf("%s: got %q (%v), want %q", file, got, err, want)
+		}
+	}
+}
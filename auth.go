@@ -0,0 +1,360 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var ErrNoCredentials = errors.New("not logged in")
+
+type CredentialStore interface {
+	Get(service string) (string, error)
+	Set(service, token string) error
+	Delete(service string) error
+}
+
+// FileStore keeps credentials in a JSON file only readable by its owner.
+type FileStore struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// DefaultStore returns a FileStore saving credentials in the configuration
+// directory of the user.
+func DefaultStore() *FileStore {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	name := programName()
+	return &FileStore{
+		Path: filepath.Join(dir, name, "credentials.json"),
+	}
+}
+
+func (f *FileStore) Get(service string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	set, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	token, ok := set[service]
+	if !ok {
+		return "", ErrNoCredentials
+	}
+	return token, nil
+}
+
+func (f *FileStore) Set(service, token string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	set, err := f.load()
+	if err != nil {
+		return err
+	}
+	set[service] = token
+	return f.save(set)
+}
+
+func (f *FileStore) Delete(service string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	set, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := set[service]; !ok {
+		return ErrNoCredentials
+	}
+	delete(set, service)
+	return f.save(set)
+}
+
+func (f *FileStore) load() (map[string]string, error) {
+	set := make(map[string]string)
+	buf, err := os.ReadFile(f.Path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+		return set, err
+	}
+	return set, json.Unmarshal(buf, &set)
+}
+
+func (f *FileStore) save(set map[string]string) error {
+	buf, err := json.Marshal(set)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0700); err != nil {
+		return err
+	}
+	return WriteFileAtomic(f.Path, buf, 0600)
+}
+
+// Auth groups what is needed to acquire, store and use the token giving
+// access to a service.
+type Auth struct {
+	Service string
+	// Host is the host the token is sent to by Transport, Service when
+	// empty. The requests to the other hosts, like the ones of redirects,
+	// are sent without the token.
+	Host string
+	// Env is the name of an environment variable that, when set, takes
+	// precedence over the stored token.
+	Env string
+	// Store keeps the token. By default, it is the keyring of the system
+	// or, when there is none, DefaultStore.
+	Store CredentialStore
+	// Acquire, when set, is used by login -web to get a token without the
+	// user having to copy it, typically with DeviceFlow.
+	Acquire func(context.Context) (string, error)
+	// Identify, when set, is used by whoami to describe the owner of the
+	// token.
+	Identify func(context.Context, string) (string, error)
+}
+
+// Token returns the token from the environment or from the store.
+func (a *Auth) Token() (string, error) {
+	if a.Env != "" {
+		if token := os.Getenv(a.Env); token != "" {
+			return token, nil
+		}
+	}
+	return a.store().Get(a.Service)
+}
+
+// Transport returns a http.RoundTripper that adds the token of a to the
+// requests sent through rt to the host of a.
+func (a *Auth) Transport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return authTransport{
+		auth:  a,
+		inner: rt,
+	}
+}
+
+// Commands returns the login, logout and whoami commands for a.
+func (a *Auth) Commands() []*Command {
+	return []*Command{a.login(), a.logout(), a.whoami()}
+}
+
+func (a *Auth) login() *Command {
+	var (
+		file  string
+		stdin bool
+		web   bool
+		cmd   = Command{
+			Usage:     "login [-with-token] [-token-file file] [-web]",
+			Short:     fmt.Sprintf("log in to %s", a.Service),
+			Conflicts: [][]string{{"token-file", "with-token", "web"}},
+		}
+	)
+	cmd.Flag.StringVar(&file, "token-file", "", "read token from file")
+	cmd.Flag.BoolVar(&stdin, "with-token", false, "read token from stdin")
+	cmd.Flag.BoolVar(&web, "web", false, "log in with the browser")
+	cmd.Run = func(c *Command, args []string) error {
+		if err := c.Parse(args); err != nil {
+			return err
+		}
+		var (
+			token string
+			err   error
+		)
+		switch {
+		case file != "":
+			var buf []byte
+			if buf, err = os.ReadFile(file); err == nil {
+				token = string(buf)
+			}
+		case stdin:
+			token, err = readLine(c.Stdin())
+		case web:
+			if a.Acquire == nil {
+				return fmt.Errorf("%s: log in with the browser not supported", a.Service)
+			}
+			token, err = a.Acquire(c.Context())
+		default:
+			token, err = readSecret(c.Context(), c.Stdin(), c.Stderr(), fmt.Sprintf("paste your token for %s: ", a.Service))
+		}
+		if err != nil {
+			return err
+		}
+		if token = strings.TrimSpace(token); token == "" {
+			return fmt.Errorf("empty token")
+		}
+		if err := a.store().Set(a.Service, token); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.Stderr(), "logged in to %s\n", a.Service)
+		return nil
+	}
+	return &cmd
+}
+
+func (a *Auth) logout() *Command {
+	cmd := Command{
+		Usage: "logout",
+		Short: fmt.Sprintf("log out from %s", a.Service),
+	}
+	cmd.Run = func(c *Command, args []string) error {
+		if err := c.Parse(args); err != nil {
+			return err
+		}
+		err := Do(c.Context(), "remove credentials of "+a.Service, func() error {
+			return a.store().Delete(a.Service)
+		})
+		if err != nil || DryRun(c.Context()) {
+			return err
+		}
+		fmt.Fprintf(c.Stderr(), "logged out from %s\n", a.Service)
+		return nil
+	}
+	return &cmd
+}
+
+func (a *Auth) whoami() *Command {
+	cmd := Command{
+		Usage: "whoami",
+		Short: fmt.Sprintf("show the account used for %s", a.Service),
+	}
+	cmd.Run = func(c *Command, args []string) error {
+		if err := c.Parse(args); err != nil {
+			return err
+		}
+		token, err := a.Token()
+		if err != nil {
+			return err
+		}
+		who := fmt.Sprintf("logged in to %s with token %s", a.Service, maskToken(token))
+		if a.Identify != nil {
+			if who, err = a.Identify(c.Context(), token); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintln(c.Stdout(), who)
+		return nil
+	}
+	return &cmd
+}
+
+func (a *Auth) store() CredentialStore {
+	if a.Store == nil {
+		a.Store = keyringOrFile{
+			keyring: KeyringStore{Name: programName()},
+			file:    DefaultStore(),
+		}
+	}
+	return a.Store
+}
+
+func (a *Auth) host() string {
+	if a.Host != "" {
+		return a.Host
+	}
+	return a.Service
+}
+
+// keyringOrFile keeps the credentials in the keyring of the system, and in a
+// file when there is no keyring. Credentials saved in the file before the
+// keyring was available are still found.
+type keyringOrFile struct {
+	keyring KeyringStore
+	file    *FileStore
+}
+
+func (s keyringOrFile) Get(service string) (string, error) {
+	token, err := s.keyring.Get(service)
+	if errors.Is(err, ErrNoKeyring) || errors.Is(err, ErrNoCredentials) {
+		return s.file.Get(service)
+	}
+	return token, err
+}
+
+func (s keyringOrFile) Set(service, token string) error {
+	err := s.keyring.Set(service, token)
+	if errors.Is(err, ErrNoKeyring) {
+		return s.file.Set(service, token)
+	}
+	return err
+}
+
+func (s keyringOrFile) Delete(service string) error {
+	err := s.keyring.Delete(service)
+	if err != nil && !errors.Is(err, ErrNoKeyring) && !errors.Is(err, ErrNoCredentials) {
+		return err
+	}
+	ferr := s.file.Delete(service)
+	if errors.Is(ferr, ErrNoCredentials) && err == nil {
+		return nil
+	}
+	return ferr
+}
+
+type authTransport struct {
+	auth  *Auth
+	inner http.RoundTripper
+}
+
+func (t authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := t.auth.host()
+	if !strings.EqualFold(req.URL.Host, host) && !strings.EqualFold(req.URL.Hostname(), host) {
+		return t.inner.RoundTrip(req)
+	}
+	token, err := t.auth.Token()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.inner.RoundTrip(req)
+}
+
+func maskToken(token string) string {
+	if len(token) <= 8 {
+		return strings.Repeat("*", len(token))
+	}
+	return token[:4] + strings.Repeat("*", len(token)-4)
+}
+
+// readLine reads r up to the end of the line without buffering, so that the
+// next lines can be read by another reader.
+func readLine(r io.Reader) (string, error) {
+	var (
+		line []byte
+		buf  = make([]byte, 1)
+	)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				break
+			}
+			line = append(line, buf[0])
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(line) > 0 {
+				break
+			}
+			return "", err
+		}
+	}
+	return strings.TrimSpace(string(line)), nil
+}
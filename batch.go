@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+type batchStep struct {
+	line int
+	cmd  *Command
+	args []string
+}
+
+// Batch runs the commands of app given by the lines of r, one command per
+// line. Blank lines and lines starting with # are ignored, and lines can not
+// start with the global flags of the app.
+//
+// Consecutive ReadOnly commands run concurrently, at most Jobs at a time,
+// their outputs being written once they all complete, in the order of the
+// lines. The other commands run alone, in order. Batch stops at the first
+// line, or group of read-only lines, that fails.
+func Batch(app *App, r io.Reader) error {
+	interactive := app.Interactive
+	app.Interactive = true
+	defer func() {
+		app.Interactive = interactive
+	}()
+	defer app.enter()()
+
+	var (
+		fset = flag.NewFlagSet("", flag.ContinueOnError)
+		opts globals
+	)
+	opts.register(fset)
+	ctx, err := app.context(&opts)
+	if err != nil {
+		return err
+	}
+	var (
+		steps []batchStep
+		scan  = bufio.NewScanner(r)
+	)
+	for n := 1; scan.Scan(); n++ {
+		line := strings.TrimSpace(scan.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		args, err := Split(line)
+		if err == nil {
+			args, err = app.expand(ctx, args)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		c := app.lookup(args[0])
+		if c == nil {
+			return fmt.Errorf("line %d: %w", n, app.suggest(args[0]))
+		}
+		steps = append(steps, batchStep{line: n, cmd: c, args: args[1:]})
+	}
+	if err := scan.Err(); err != nil {
+		return err
+	}
+	for len(steps) > 0 {
+		n := readOnlyGroup(steps)
+		if n == 0 {
+			s := steps[0]
+			if err := app.execute(ctx, s.cmd, s.args); err != nil {
+				return fmt.Errorf("line %d: %w", s.line, err)
+			}
+			steps = steps[1:]
+			continue
+		}
+		if err := app.runGroup(ctx, steps[:n]); err != nil {
+			return err
+		}
+		steps = steps[n:]
+	}
+	return nil
+}
+
+// readOnlyGroup returns the number of steps, at the start of steps, that can
+// run concurrently. The same command never runs twice at the same time.
+func readOnlyGroup(steps []batchStep) int {
+	seen := make(map[*Command]bool)
+	for i, s := range steps {
+		if !s.cmd.ReadOnly || seen[s.cmd] {
+			return i
+		}
+		seen[s.cmd] = true
+	}
+	return len(steps)
+}
+
+func (a *App) runGroup(ctx context.Context, steps []batchStep) error {
+	var (
+		wg     sync.WaitGroup
+		sema   = make(chan struct{}, Jobs(ctx))
+		outs   = make([]bytes.Buffer, len(steps))
+		errs   = make([]bytes.Buffer, len(steps))
+		failed = make([]error, len(steps))
+	)
+	for i := range steps {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sema <- struct{}{}
+			defer func() { <-sema }()
+			s := steps[i]
+			failed[i] = a.executeTo(ctx, s.cmd, s.args, &outs[i], &errs[i])
+		}(i)
+	}
+	wg.Wait()
+
+	var list Errors
+	for i, s := range steps {
+		a.stdout().Write(outs[i].Bytes())
+		a.stderr().Write(errs[i].Bytes())
+		if failed[i] != nil {
+			list.Add(fmt.Errorf("line %d: %w", s.line, failed[i]))
+		}
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
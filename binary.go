@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"context"
+	"io"
+)
+
+type binaryKey struct{}
+
+type binaryOutput struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// chatter returns the writer to use for the messages meant for the user that
+// should go to w. When the command writes binary data on its standard output
+// and w is that output, the messages go to the standard error instead.
+func chatter(ctx context.Context, w io.Writer) io.Writer {
+	b, ok := ctx.Value(binaryKey{}).(binaryOutput)
+	if ok && w == b.stdout {
+		return b.stderr
+	}
+	return w
+}
+
+func withBinary(ctx context.Context, stdout, stderr io.Writer) context.Context {
+	return context.WithValue(ctx, binaryKey{}, binaryOutput{stdout: stdout, stderr: stderr})
+}
@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Bind registers in set a flag for each field of the struct pointed to by opts
+// that has a flag tag. The behaviour of each flag is controlled by the
+// following tags:
+//
+//	flag:"name,short"  long and optional short name of the flag
+//	help:"..."         usage of the flag
+//	default:"..."      default value of the flag
+//	env:"NAME"         environment variable overriding the default value
+//
+// Fields can be of any basic type, time.Duration or any type whose pointer
+// implements flag.Value.
+func Bind(set *flag.FlagSet, opts interface{}) error {
+	v := reflect.ValueOf(opts)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind: expected pointer to struct, got %T", opts)
+	}
+	return bindStruct(set, v.Elem())
+}
+
+// Bind registers the fields of opts as flags of the command. See Bind.
+func (c *Command) Bind(opts interface{}) error {
+	return Bind(&c.Flag, opts)
+}
+
+func bindStruct(set *flag.FlagSet, v reflect.Value) error {
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		var (
+			field = typ.Field(i)
+			value = v.Field(i)
+			tag   = field.Tag.Get("flag")
+		)
+		if field.PkgPath != "" {
+			continue
+		}
+		if tag == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := bindStruct(set, value); err != nil {
+				return err
+			}
+			continue
+		}
+		if tag == "" || tag == "-" {
+			continue
+		}
+		fv, err := fieldValue(value)
+		if err != nil {
+			return fmt.Errorf("bind %s: %w", field.Name, err)
+		}
+		if def, ok := field.Tag.Lookup("default"); ok {
+			if err := fv.Set(def); err != nil {
+				return fmt.Errorf("bind %s: invalid default value %q: %w", field.Name, def, err)
+			}
+		}
+		if env := field.Tag.Get("env"); env != "" {
+			if str, ok := os.LookupEnv(env); ok {
+				if err := fv.Set(str); err != nil {
+					return fmt.Errorf("bind %s: invalid value %q in %s: %w", field.Name, str, env, err)
+				}
+			}
+		}
+		help := field.Tag.Get("help")
+		for _, name := range strings.Split(tag, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				set.Var(fv, name, help)
+			}
+		}
+	}
+	return nil
+}
+
+func fieldValue(v reflect.Value) (flag.Value, error) {
+	if fv, ok := v.Addr().Interface().(flag.Value); ok {
+		return fv, nil
+	}
+	switch v.Kind() {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64:
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return nil, fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return reflectValue{v}, nil
+}
+
+type reflectValue struct {
+	value reflect.Value
+}
+
+func (r reflectValue) Set(str string) error {
+	v := r.value
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(str)
+		if err == nil {
+			v.SetInt(int64(d))
+		}
+		return err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(str)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(str, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(str, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(str, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+	}
+	return nil
+}
+
+func (r reflectValue) String() string {
+	if !r.value.IsValid() {
+		return ""
+	}
+	return fmt.Sprint(r.value.Interface())
+}
+
+func (r reflectValue) IsBoolFlag() bool {
+	return r.value.IsValid() && r.value.Kind() == reflect.Bool
+}
+
+func (r reflectValue) Type() string {
+	if r.value.Type() == durationType {
+		return "duration"
+	}
+	return r.value.Kind().String()
+}
+
+// applyEnv sets the flags of the command having a variable in the environment
+// and returns their names. See App.EnvPrefix. The flag yes of the commands
+// having dangerous flags is left alone, for these flags to be confirmed.
+func (c *Command) applyEnv(prefix string) ([]string, error) {
+	var (
+		applied []string
+		err     error
+	)
+	c.Flag.VisitAll(func(f *flag.Flag) {
+		if err != nil || (f.Name == "yes" && len(c.Dangerous) > 0) {
+			return
+		}
+		env := envName(prefix, f.Name)
+		str, ok := os.LookupEnv(env)
+		if !ok {
+			return
+		}
+		if e := f.Value.Set(str); e != nil {
+			err = fmt.Errorf("%s: invalid value %q for flag -%s: %w", env, str, f.Name, e)
+			return
+		}
+		applied = append(applied, f.Name)
+	})
+	return applied, err
+}
+
+func envName(prefix, name string) string {
+	return strings.ToUpper(strings.ReplaceAll(prefix+"_"+name, "-", "_"))
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+var ErrNotCached = errors.New("not in cache")
+
+// Cache stores arbitrary data on disk under keys of any form.
+type Cache struct {
+	Dir string
+}
+
+// DefaultCache returns a Cache in the cache directory of the user.
+func DefaultCache() *Cache {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	name := programName()
+	return &Cache{
+		Dir: filepath.Join(dir, name),
+	}
+}
+
+func (c *Cache) Get(key string) ([]byte, error) {
+	buf, err := os.ReadFile(c.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		err = ErrNotCached
+	}
+	return buf, err
+}
+
+func (c *Cache) Put(key string, data []byte) error {
+	file := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	return WriteFileAtomic(file, data, 0600)
+}
+
+func (c *Cache) Delete(key string) error {
+	err := os.Remove(c.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+	return err
+}
+
+// Clear removes all the entries of the cache.
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.Dir)
+}
+
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	str := hex.EncodeToString(sum[:])
+	return filepath.Join(c.Dir, str[:2], str[2:])
+}
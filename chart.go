@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	sparks = []rune("▁▂▃▄▅▆▇█")
+	// eighths of a block, from the smallest.
+	blocks = []rune("▏▎▍▌▋▊▉█")
+)
+
+// Sparkline returns a line of small bars, one per value, scaled between the
+// smallest and the largest values. NaN and infinite values are left blank.
+func Sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if isFinite(v) {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+	}
+	var b strings.Builder
+	for _, v := range values {
+		if !isFinite(v) {
+			b.WriteRune(' ')
+			continue
+		}
+		i := len(sparks) / 2
+		if hi > lo {
+			i = int((v - lo) / (hi - lo) * float64(len(sparks)-1))
+		}
+		b.WriteRune(sparks[i])
+	}
+	return b.String()
+}
+
+// Bar is an entry of the chart printed by Bars.
+type Bar struct {
+	Label string
+	Value float64
+}
+
+// Bars prints a horizontal bar for each entry of bars, the longest being
+// width characters, followed by its value formatted with format and an axis
+// giving the scale. When format is nil, values are printed as numbers.
+// SizeFormat and DurationFormat can be used for sizes and durations. The
+// width defaults to 40 and can not be less than 2, the width of the axis.
+func Bars(w io.Writer, bars []Bar, width int, format func(float64) string) error {
+	switch {
+	case width <= 0:
+		width = 40
+	case width < 2:
+		return fmt.Errorf("%d: chart too narrow (expected at least 2)", width)
+	}
+	if format == nil {
+		format = func(v float64) string {
+			return fmt.Sprintf("%g", v)
+		}
+	}
+	var (
+		max   float64
+		label int
+		buf   strings.Builder
+	)
+	for _, b := range bars {
+		if isFinite(b.Value) {
+			max = math.Max(max, b.Value)
+		}
+		if n := utf8.RuneCountInString(b.Label); n > label {
+			label = n
+		}
+	}
+	for _, b := range bars {
+		bar := barString(b.Value, max, width)
+		fmt.Fprintf(&buf, "%-*s %s%s %s\n", label, b.Label, bar, strings.Repeat(" ", width-utf8.RuneCountInString(bar)), format(b.Value))
+	}
+	var (
+		lo = format(0)
+		hi = format(max)
+	)
+	fmt.Fprintf(&buf, "%*s └%s┘\n", label, "", strings.Repeat("─", width-2))
+	fmt.Fprintf(&buf, "%*s %s%*s\n", label, "", lo, width-utf8.RuneCountInString(lo), hi)
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// SizeFormat formats v as a Size.
+func SizeFormat(v float64) string {
+	return Size(v).Human(IEC)
+}
+
+// DurationFormat formats v, a number of nanoseconds, as a duration.
+func DurationFormat(v float64) string {
+	return HumanDuration(time.Duration(v))
+}
+
+// barString returns a bar of at most width characters for value, max being
+// the value of the longest bar. Non zero values always have a visible bar,
+// NaN and infinite ones have none.
+func barString(value, max float64, width int) string {
+	if max <= 0 || value <= 0 || !isFinite(value) {
+		return ""
+	}
+	eighths := int(math.Round(value / max * float64(width*8)))
+	if eighths == 0 {
+		eighths = 1
+	}
+	bar := strings.Repeat(string(blocks[7]), eighths/8)
+	if n := eighths % 8; n > 0 {
+		bar += string(blocks[n-1])
+	}
+	return bar
+}
+
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// Checkpoint records the items already processed by a batch command, so that
+// a run interrupted can be resumed with the --resume flag without doing the
+// completed work again.
+//
+// Name identifies the batch, for instance the command and its input. The
+// items are kept in the state directory of the user until Finish is called.
+type Checkpoint struct {
+	Name   string
+	Resume bool
+
+	mu   sync.Mutex
+	done map[string]bool
+	file *os.File
+}
+
+// Flags registers the --resume flag in set.
+func (c *Checkpoint) Flags(set *flag.FlagSet) {
+	set.BoolVar(&c.Resume, "resume", c.Resume, "skip the items processed by the previous run")
+}
+
+// Open loads the items recorded by the previous run when Resume is set and
+// discards them otherwise.
+func (c *Checkpoint) Open() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	file := c.path()
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	c.done = make(map[string]bool)
+	if !c.Resume {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	} else if err := c.load(file); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	c.file = f
+	return nil
+}
+
+// Done reports whether id was processed by a previous run.
+func (c *Checkpoint) Done(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.done[id]
+}
+
+// Len returns the number of items processed.
+func (c *Checkpoint) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.done)
+}
+
+// Mark records that id is processed. It is safe for concurrent use.
+func (c *Checkpoint) Mark(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return fmt.Errorf("checkpoint %s: not open", c.Name)
+	}
+	if c.done[id] {
+		return nil
+	}
+	buf, _ := json.Marshal(id)
+	if _, err := c.file.Write(append(buf, '\n')); err != nil {
+		return err
+	}
+	c.done[id] = true
+	return nil
+}
+
+// Close closes the checkpoint, keeping the items recorded for the next run.
+func (c *Checkpoint) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
+
+// Finish closes the checkpoint and forgets its items, once the batch is
+// complete.
+func (c *Checkpoint) Finish() error {
+	if err := c.Close(); err != nil {
+		return err
+	}
+	err := os.Remove(c.path())
+	if os.IsNotExist(err) {
+		err = nil
+	}
+	return err
+}
+
+func (c *Checkpoint) load(file string) error {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scan := bufio.NewScanner(f)
+	for scan.Scan() {
+		var id string
+		// the last line is incomplete when the previous run was killed
+		// while writing it.
+		if json.Unmarshal(scan.Bytes(), &id) == nil {
+			c.done[id] = true
+		}
+	}
+	return scan.Err()
+}
+
+func (c *Checkpoint) path() string {
+	sum := sha256.Sum256([]byte(c.Name))
+	return filepath.Join(userStateDir(), "checkpoints", hex.EncodeToString(sum[:]))
+}
+
+// userStateDir returns the directory of the data the app keeps between its
+// runs, following XDG_STATE_HOME where it applies.
+func userStateDir() string {
+	name := programName()
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, name)
+	}
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		dir, err := os.UserConfigDir()
+		if err == nil {
+			return filepath.Join(dir, name, "state")
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), name)
+	}
+	return filepath.Join(home, ".local", "state", name)
+}
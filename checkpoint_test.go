@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	defer setenv(t, "XDG_STATE_HOME", t.TempDir())()
+
+	open := func(resume bool) *Checkpoint {
+		t.Helper()
+		c := Checkpoint{Name: "sync photos", Resume: resume}
+		if err := c.Open(); err != nil {
+			t.Fatalf("open: %s", err)
+		}
+		return &c
+	}
+
+	// the first run is interrupted after two items.
+	c := open(false)
+	for _, id := range []string{"a", "b"} {
+		if err := c.Mark(id); err != nil {
+			t.Fatalf("mark %s: %s", id, err)
+		}
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Mark("c"); err == nil {
+		t.Errorf("mark should fail once the checkpoint is closed")
+	}
+
+	// resuming skips them and goes on.
+	c = open(true)
+	if !c.Done("a") || !c.Done("b") || c.Done("c") || c.Len() != 2 {
+		t.Fatalf("resume: got %d items, want a and b", c.Len())
+	}
+	c.Mark("c")
+	c.Mark("a")
+	c.Close()
+
+	c = open(true)
+	if c.Len() != 3 {
+		t.Errorf("second resume: got %d items, want 3", c.Len())
+	}
+	if err := c.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the items are forgotten once the batch finished.
+	c = open(true)
+	if c.Len() != 0 {
+		t.Errorf("after finish: got %d items, want none", c.Len())
+	}
+	c.Mark("x")
+	c.Close()
+
+	// and without -resume the previous items are discarded.
+	c = open(false)
+	defer c.Finish()
+	if c.Done("x") {
+		t.Errorf("without resume: x should not be done")
+	}
+	other := Checkpoint{Name: "sync videos", Resume: true}
+	if err := other.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer other.Finish()
+	if other.Len() != 0 {
+		t.Errorf("batches with another name should not share their items")
+	}
+}
+
+func TestCheckpointPartialLine(t *testing.T) {
+	defer setenv(t, "XDG_STATE_HOME", t.TempDir())()
+
+	c := Checkpoint{Name: "killed"}
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	c.Mark("a")
+	c.file.WriteString(`"trunc`)
+	c.Close()
+
+	c = Checkpoint{Name: "killed", Resume: true}
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Finish()
+	if !c.Done("a") || c.Done("trunc") || c.Len() != 1 {
+		t.Errorf("got %d items, want only a", c.Len())
+	}
+}
+
+// setenv sets the variable key to value and returns the function restoring
+// its previous value.
+func setenv(t *testing.T, key, value string) func() {
+	old, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
@@ -1,11 +1,13 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
 	"sort"
@@ -16,6 +18,8 @@ import (
 	"github.com/midbel/distance"
 )
 
+// Version, BuildTime, CompileWith and CompileHost are meant to be set at link
+// time and only read afterwards. Use App.Info to change them at run time.
 var (
 	Version     string
 	BuildTime   string
@@ -24,7 +28,8 @@ var (
 )
 
 const (
-	BadExitCode = 1
+	BadExitCode     = 1
+	TimeoutExitCode = 124
 )
 
 type ExitError struct {
@@ -57,90 +62,104 @@ func Usage(cmd, help string, cs []*Command) func() {
 			Name:     cmd,
 			Commands: cs,
 		}
-		fs := template.FuncMap{
-			"join": strings.Join,
-		}
-		t := template.Must(template.New("help").Funcs(fs).Parse(help))
+		t := template.Must(template.New("help").Funcs(contextFuncs(context.Background())).Parse(help))
 		t.Execute(os.Stderr, data)
 
-		os.Exit(2)
+		exit(2)
 	}
 	return f
 }
 
 func RunAndExit(cs []*Command, usage func()) {
 	if err := Run(cs, usage); err != nil {
-		var (
-			code    = BadExitCode
-			exit    *ExitError
-			suggest SuggestError
-			list    []string
-		)
-		if errors.As(err, &suggest) {
-			list = suggest.Similar(cs)
-		} else if errors.As(err, &exit) {
-			code, err = exit.Code, exit.Err
-		}
-		fmt.Fprintln(os.Stderr, err)
-		if len(list) > 0 {
-			fmt.Fprintln(os.Stderr)
-			fmt.Fprintln(os.Stderr, "most similar commands are:")
-			for _, c := range list {
-				fmt.Fprintln(os.Stderr, " ", c)
-			}
-		}
-		os.Exit(code)
+		exit(report(os.Stderr, err, cs, ExitFirst))
 	}
 }
 
-func Run(cs []*Command, usage func()) error {
+// Main runs c as the only command of the program and exits.
+func Main(c *Command) {
+	app := App{
+		Main: c,
+	}
+	exit(app.Execute(os.Args[1:]))
+}
+
+// ExitCode gives the exit code of a program whose main command returned err.
+// For Errors, it is the exit code of the first error.
+func ExitCode(err error) int {
 	var (
-		fset    = flag.NewFlagSet("", flag.ContinueOnError)
-		version = struct {
-			Short bool
-			Long  bool
-		}{}
+		exit  *ExitError
+		child *exec.ExitError
+		errs  Errors
 	)
-	fset.Usage = usage
-	fset.SetOutput(io.Discard)
-	fset.BoolVar(&version.Short, "v", false, "")
-	fset.BoolVar(&version.Long, "version", false, "")
-	if err := fset.Parse(os.Args[1:]); err != nil {
-		if !strings.HasPrefix(err.Error(), "flag provided but not defined") {
-			return err
-		}
-		return tryDefault(cs)
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &errs) && len(errs) > 0:
+		return ExitCode(errs[0])
+	case errors.Is(err, flag.ErrHelp):
+		return 2
+	case errors.As(err, &exit):
+		return exit.Code
+	case errors.Is(err, ErrOffline):
+		return OfflineExitCode
+	case errors.As(err, &child):
+		return processExitCode(child.ProcessState)
+	default:
+		return BadExitCode
 	}
+}
 
-	if version.Short || version.Long || (flag.NArg() > 0 && flag.Arg(0) == "version") {
-		printVersion()
-		return nil
-	}
-	if fset.NArg() == 0 || fset.Arg(0) == "help" {
-		fset.Usage()
-		return nil
+func report(w io.Writer, err error, cs []*Command, policy ExitPolicy) int {
+	var (
+		code    = exitCode(err, policy)
+		exit    *ExitError
+		errs    Errors
+		suggest SuggestError
+		list    []string
+	)
+	switch {
+	case errors.As(err, &suggest):
+		list = suggest.Similar(cs)
+	case errors.As(err, &errs) && len(errs) > 1:
+		errs.print(w)
+		return code
+	case errors.As(err, &exit):
+		err = exit.Err
 	}
-
-	set := make(map[string]*Command)
-	for _, c := range cs {
-		if !c.Runnable() {
-			continue
-		}
-		set[c.String()] = c
-		for _, a := range c.Alias {
-			set[a] = c
+	fmt.Fprintln(w, err)
+	if len(list) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "most similar commands are:")
+		for _, c := range list {
+			fmt.Fprintln(w, " ", c)
 		}
 	}
-	args := fset.Args()
-	if c, ok := set[fset.Arg(0)]; ok && c.Runnable() {
-		c.Flag.Usage = c.Help
-		return c.Run(c, args[1:])
+	return code
+}
+
+func Run(cs []*Command, usage func()) error {
+	return RunArgs(cs, usage, os.Args[1:])
+}
+
+// RunArgs is like Run but dispatches args instead of the arguments of the
+// process.
+func RunArgs(cs []*Command, usage func(), args []string) error {
+	app := App{
+		Commands: cs,
+		Usage:    usage,
 	}
-	return Suggest(fset.Arg(0))
+	return app.Run(args)
 }
 
 type SuggestError struct {
 	Cmd string
+	// Prog is the name of the program given in the message. It defaults to
+	// the name of the executable.
+	Prog string
+	// Counts, when set, gives how many times each command was used. Equally
+	// close suggestions are ranked by it.
+	Counts map[string]int
 }
 
 func Suggest(cmd string) error {
@@ -157,91 +176,489 @@ func (e SuggestError) Similar(others []*Command) []string {
 		}
 		list = append(list, c.String())
 	}
-	return distance.Levenshtein(e.Cmd, list)
+	list = distance.Levenshtein(e.Cmd, list)
+	if len(e.Counts) > 0 {
+		sort.SliceStable(list, func(i, j int) bool {
+			di, dj := editDistance(e.Cmd, list[i]), editDistance(e.Cmd, list[j])
+			if di != dj {
+				return di < dj
+			}
+			return e.Counts[list[i]] > e.Counts[list[j]]
+		})
+	}
+	return list
+}
+
+// programName returns the name of the executable, without the .exe suffix of
+// Windows nor the dash that some shells put in front of the name of login
+// shells.
+func programName() string {
+	name := filepath.Base(os.Args[0])
+	if ext := filepath.Ext(name); strings.EqualFold(ext, ".exe") {
+		name = name[:len(name)-len(ext)]
+	}
+	return strings.TrimLeft(name, "-")
 }
 
 func (e SuggestError) Error() string {
-	exec := filepath.Base(os.Args[0])
+	exec := e.Prog
+	if exec == "" {
+		exec = programName()
+	}
 	return fmt.Sprintf(`%s: unknown sub-command. run "%s help" for usage`, e.Cmd, exec)
 }
 
-func tryDefault(cs []*Command) error {
-	var cmd *Command
-	for i := range cs {
-		if cs[i].Default {
-			cmd = cs[i]
-			break
-		}
-	}
-	if cmd != nil {
-		cmd.Flag.Usage = cmd.Help
-		return cmd.Run(cmd, os.Args[1:])
-	}
-	return fmt.Errorf("no sub-command given!")
+type VersionInfo struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	OS          string `json:"os"`
+	Arch        string `json:"arch"`
+	BuildTime   string `json:"build_time"`
+	CompileWith string `json:"compile_with,omitempty"`
+	CompileHost string `json:"compile_host,omitempty"`
 }
 
-func printVersion() {
-	var (
-		name    = filepath.Base(os.Args[0])
-		syst    = runtime.GOOS
-		arch    = runtime.GOARCH
-		version = Version
-		buf     strings.Builder
-	)
-	if BuildTime == "" {
-		t := time.Now()
-		if p, err := os.Executable(); err == nil {
+// versionInfo describes the program from the package variables, usually set
+// at link time, overridden by the Info of the app running the command.
+func versionInfo(ctx context.Context) VersionInfo {
+	info := VersionInfo{
+		Name:        programName(),
+		Version:     Version,
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		BuildTime:   BuildTime,
+		CompileWith: CompileWith,
+		CompileHost: CompileHost,
+	}
+	if a := appFrom(ctx); a != nil {
+		info = info.merge(a.Info)
+	}
+	if info.BuildTime == "" {
+		t := Now(ctx)
+		if p, err := os.Executable(); err == nil && !Deterministic(ctx) {
 			if i, err := os.Stat(p); err == nil {
 				t = i.ModTime().Truncate(time.Hour)
 			}
 		}
-		BuildTime = t.UTC().Format(time.RFC3339)
+		info.BuildTime = t.UTC().Format(time.RFC3339)
+	}
+	if info.Version == "" {
+		info.Version = "unknown"
 	}
-	if version == "" {
-		version = "unknown"
+	return info
+}
+
+func (v VersionInfo) merge(other VersionInfo) VersionInfo {
+	set := func(dst *string, src string) {
+		if src != "" {
+			*dst = src
+		}
 	}
+	set(&v.Name, other.Name)
+	set(&v.Version, other.Version)
+	set(&v.OS, other.OS)
+	set(&v.Arch, other.Arch)
+	set(&v.BuildTime, other.BuildTime)
+	set(&v.CompileWith, other.CompileWith)
+	set(&v.CompileHost, other.CompileHost)
+	return v
+}
 
-	buf.WriteString(name)
+func printVersion(ctx context.Context, w io.Writer) {
+	var (
+		info = versionInfo(ctx)
+		buf  strings.Builder
+	)
+	buf.WriteString(info.Name)
 	buf.WriteRune('-')
-	buf.WriteString(version)
+	buf.WriteString(info.Version)
 	buf.WriteRune(' ')
 
-	buf.WriteString(syst)
+	buf.WriteString(info.OS)
 	buf.WriteRune('/')
-	buf.WriteString(arch)
+	buf.WriteString(info.Arch)
 	buf.WriteRune(' ')
-	buf.WriteString(BuildTime)
+	buf.WriteString(info.BuildTime)
 
-	if CompileWith != "" {
+	if info.CompileWith != "" {
 		buf.WriteString(" (compile with ")
-		buf.WriteString(CompileWith)
-		if CompileHost != "" {
+		buf.WriteString(info.CompileWith)
+		if info.CompileHost != "" {
 			buf.WriteString(" - ")
-			buf.WriteString(CompileHost)
+			buf.WriteString(info.CompileHost)
 		}
 		buf.WriteString(")")
 	}
-	fmt.Fprintln(os.Stdout, buf.String())
+	fmt.Fprintln(w, buf.String())
+}
+
+type Example struct {
+	Cmd  string `json:"cmd"`
+	Desc string `json:"description,omitempty"`
+	// Output, when set, is the expected output of Cmd. The hidden selftest
+	// command runs the examples having one and reports the differences.
+	Output string `json:"output,omitempty"`
 }
 
 type Command struct {
-	Desc    string
-	Usage   string
-	Short   string
-	Default bool
-	Alias   []string
-	Flag    flag.FlagSet
-	Run     func(*Command, []string) error
+	Desc     string
+	Usage    string
+	Short    string
+	Default  bool
+	Alias    []string
+	Timeout  time.Duration
+	Encoding string
+	Jobs     int
+	Output   string
+	Template string
+	Flag     flag.FlagSet
+	// Args describes the positional arguments checked and converted by Parse.
+	// When Usage only gives the name of the command, its usage is generated
+	// from Args.
+	Args []Argument
+
+	// Conflicts lists groups of flags that can not be used together.
+	Conflicts [][]string
+	// Requires maps a flag to the flags that have to be given with it.
+	Requires map[string][]string
+	// ReadOnly tells that the command changes nothing, so that it can run
+	// concurrently with the other read-only commands. See Batch.
+	ReadOnly bool
+	// Dangerous lists the flags whose use has to be confirmed by typing the
+	// text returned by Confirm for the arguments, or the name of the command
+	// when Confirm is nil. The -yes flag, added to the command, skips it.
+	Dangerous []string
+	Confirm   func(args []string) string
+	// Examples are shown in the help of the command.
+	Examples []Example
+	// Budget is the usual duration of the command. When a run takes longer,
+	// a hint is printed to the user: Hint if set, otherwise a generic one
+	// about the flags that could make the command faster.
+	Budget time.Duration
+	Hint   string
+	// Complete, when set, gives the candidates for the completion of the
+	// argument following args, filtered by the app.
+	Complete func(ctx context.Context, args []string) []string
+	// Limits, when set, are applied by Parse once the flags are parsed. Their
+	// flags are added to the command.
+	Limits *Limits
+	// Binary tells that the command writes binary data on its standard
+	// output, like an archive. The output is not transcoded and the messages,
+	// warnings and progress of the command written there go to the standard
+	// error instead.
+	Binary bool
+	// RunResult, when set instead of Run, runs the command and gives its
+	// Result to the package, which prints it. See Result.
+	RunResult func(*Command, []string) (*Result, error)
+
+	Run func(*Command, []string) error
+
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	out    io.Writer
+	err    io.Writer
+	values map[string]interface{}
+	yes    bool
+	ran    bool
+}
+
+// Context returns the context of the running command. When the command has a
+// Timeout, the deadline is computed on the first call so Context should be
+// called once the flags of the command have been parsed.
+func (c *Command) Context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	c.ctx = c.parent
+	if c.ctx == nil {
+		c.ctx = context.Background()
+	}
+	if c.Timeout > 0 {
+		c.ctx, c.cancel = context.WithTimeout(c.ctx, c.Timeout)
+	}
+	if c.Jobs > 0 {
+		c.ctx = withJobs(c.ctx, c.Jobs)
+	}
+	if c.Binary {
+		c.ctx = withBinary(c.ctx, c.Stdout(), c.Stderr())
+	}
+	return c.ctx
+}
+
+// OnExit registers fn to be run once the app running c returns or before the
+// process exits. See App.OnExit.
+func (c *Command) OnExit(fn func()) {
+	if a := appFrom(c.Context()); a != nil {
+		a.OnExit(fn)
+		return
+	}
+	atExit(fn)
+}
+
+func (c *Command) Stdin() io.Reader {
+	if c.stdin == nil {
+		return os.Stdin
+	}
+	return c.stdin
+}
+
+// Stdout returns the standard output of the command, transcoded according to
+// its Encoding unless the command is Binary.
+func (c *Command) Stdout() io.Writer {
+	if c.out == nil && c.Binary {
+		c.out = c.stdout
+		if c.out == nil {
+			c.out = os.Stdout
+		}
+	}
+	if c.out == nil {
+		c.out = c.output(c.stdout, os.Stdout)
+	}
+	return c.out
+}
+
+// Stderr returns the standard error of the command, transcoded according to
+// its Encoding.
+func (c *Command) Stderr() io.Writer {
+	if c.err == nil {
+		c.err = c.output(c.stderr, os.Stderr)
+	}
+	return c.err
+}
+
+func (c *Command) output(w, def io.Writer) io.Writer {
+	if w == nil {
+		w = def
+	}
+	e, err := Encode(w, c.Encoding)
+	if err != nil {
+		return w
+	}
+	return e
+}
+
+// Parse parses the flags of the command and checks the relations declared
+// between them by Conflicts and Requires. The flags first get the values
+// found in the configuration loaded by the app, if any, then the ones found in
+// the environment, see App.EnvPrefix.
+func (c *Command) Parse(args []string) error {
+	seen := make(map[string]bool)
+	if c.parent != nil {
+		applied, err := c.applyConfig(ConfigFrom(c.parent))
+		if err != nil {
+			return err
+		}
+		for _, name := range applied {
+			seen[name] = true
+		}
+		if a := appFrom(c.parent); a != nil && a.EnvPrefix != "" {
+			applied, err := c.applyEnv(a.EnvPrefix)
+			if err != nil {
+				return err
+			}
+			for _, name := range applied {
+				seen[name] = true
+			}
+		}
+	}
+	if err := c.Flag.Parse(args); err != nil {
+		return err
+	}
+	c.Flag.Visit(func(f *flag.Flag) {
+		seen[f.Name] = true
+		if v, ok := f.Value.(*File); ok {
+			v.ctx = c.Context()
+		}
+	})
+	for _, group := range c.Conflicts {
+		var given []string
+		for _, name := range group {
+			if seen[name] {
+				given = append(given, "-"+name)
+			}
+		}
+		if len(given) > 1 {
+			return fmt.Errorf("flags %s can not be used together", strings.Join(given, ", "))
+		}
+	}
+	names := make([]string, 0, len(c.Requires))
+	for name := range c.Requires {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !seen[name] {
+			continue
+		}
+		for _, o := range c.Requires[name] {
+			if !seen[o] {
+				return fmt.Errorf("flag -%s requires -%s", name, o)
+			}
+		}
+	}
+	if c.Limits != nil {
+		if err := c.Limits.Apply(); err != nil {
+			return err
+		}
+	}
+	if err := c.parseArgs(c.Flag.Args()); err != nil {
+		return err
+	}
+	return c.confirm(seen)
+}
+
+func (c *Command) prepare() {
+	if c.Timeout > 0 && c.Flag.Lookup("timeout") == nil {
+		c.Flag.DurationVar(&c.Timeout, "timeout", c.Timeout, "abort command after given duration")
+	}
+	if c.Encoding != "" && c.Flag.Lookup("encoding") == nil {
+		c.Flag.Var(encodingValue{&c.Encoding}, "encoding", "encoding of the command output")
+	}
+	if c.Jobs > 0 && c.Flag.Lookup("jobs") == nil {
+		c.Flag.IntVar(&c.Jobs, "jobs", c.Jobs, "number of tasks to run in parallel")
+	}
+	if len(c.Dangerous) > 0 && c.Flag.Lookup("yes") == nil {
+		c.Flag.BoolVar(&c.yes, "yes", false, "do not ask to confirm dangerous flags")
+	}
+	if c.Limits != nil && c.Flag.Lookup("nice") == nil {
+		c.Limits.Flags(&c.Flag)
+	}
+	if c.Output != "" && c.Flag.Lookup("output") == nil {
+		c.Flag.Var(outputValue{&c.Output}, "output", "output format (text, json, yaml or csv)")
+		if c.Flag.Lookup("o") == nil {
+			c.Flag.Var(outputValue{&c.Output}, "o", "output format (text, json, yaml or csv)")
+		}
+	}
+}
+
+func (c *Command) execute(args []string, usage func()) error {
+	c.Flag.Usage = usage
+	c.prepare()
+	if c.ran {
+		resetFlags(&c.Flag)
+	}
+	c.ran = true
+	defer c.reset()
+	defer func() {
+		if r := recover(); r != nil {
+			runAtExit()
+			panic(r)
+		}
+	}()
+
+	var clock Clock = SystemClock{}
+	if c.parent != nil {
+		clock = ClockFrom(c.parent)
+		c.Flag.VisitAll(func(f *flag.Flag) {
+			if v, ok := f.Value.(*Time); ok {
+				v.ctx = c.parent
+			}
+		})
+	}
+	start := clock.Now()
+	err := c.run(args)
+	if err != nil && c.expired(err) {
+		err = Exit(fmt.Errorf("%s: timeout after %s", c, c.Timeout), TimeoutExitCode)
+	}
+	if err == nil {
+		c.checkBudget(clock.Now().Sub(start))
+	}
+	return err
+}
+
+func (c *Command) checkBudget(elapsed time.Duration) {
+	if c.Budget <= 0 || elapsed <= c.Budget {
+		return
+	}
+	if c.parent != nil && Deterministic(c.parent) {
+		return
+	}
+	hint := c.Hint
+	if hint == "" {
+		var opts []string
+		for _, name := range []string{"jobs", "no-cache"} {
+			if c.Flag.Lookup(name) != nil {
+				opts = append(opts, "-"+name)
+			}
+		}
+		if len(opts) > 0 {
+			hint = fmt.Sprintf("options like %s can make it faster", joinChoices(opts))
+		}
+	}
+	msg := fmt.Sprintf("hint: %s took %s, usually less than %s", c, elapsed.Round(time.Millisecond), c.Budget)
+	if hint != "" {
+		msg += " - " + hint
+	}
+	Message(c.Context(), c.Stderr(), Info, "%s", msg)
+}
+
+func (c *Command) expired(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	return c.ctx != nil && errors.Is(c.ctx.Err(), context.DeadlineExceeded)
+}
+
+func (c *Command) reset() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.parent, c.ctx, c.cancel = nil, nil, nil
+	c.stdin, c.stdout, c.stderr = nil, nil, nil
+	for _, w := range []io.Writer{c.out, c.err} {
+		if e, ok := w.(*encoder); ok {
+			e.Close()
+		}
+	}
+	c.out, c.err = nil, nil
+	c.values = nil
+	c.yes = false
+}
+
+// resetFlags gives the flags of set their default value back and forgets the
+// ones given, so that a command run again, like in Shell or Batch, does not
+// inherit the flags of its previous run.
+func resetFlags(set *flag.FlagSet) {
+	fresh := flag.NewFlagSet(set.Name(), set.ErrorHandling())
+	fresh.Usage = set.Usage
+	fresh.SetOutput(set.Output())
+	set.VisitAll(func(f *flag.Flag) {
+		if f.Value.String() != f.DefValue {
+			switch v := f.Value.(type) {
+			case *Patterns:
+				*v = nil
+			case *ConfigPaths:
+				*v = nil
+			case resetter:
+				v.reset(f.DefValue)
+			default:
+				f.Value.Set(f.DefValue)
+			}
+		}
+		fresh.Var(f.Value, f.Name, f.Usage)
+		fresh.Lookup(f.Name).DefValue = f.DefValue
+	})
+	*set = *fresh
+}
+
+// resetter is implemented by the flag values that Set can not give their
+// default back, like the ones refusing an empty string. reset gives the value
+// the state it had when registered with def as default.
+type resetter interface {
+	reset(def string)
 }
 
 func (c *Command) Help() {
-	if len(c.Desc) > 0 {
-		fmt.Fprintf(os.Stderr, "%s\n", strings.TrimSpace(c.Desc))
-	} else {
-		fmt.Fprintln(os.Stderr, c.Short)
+	ctx := c.parent
+	if ctx == nil {
+		ctx = context.Background()
 	}
-	fmt.Fprintf(os.Stderr, "\nusage: %s\n", c.Usage)
-	os.Exit(2)
+	c.printHelp(ctx, os.Stderr)
+	exit(2)
 }
 
 func (c *Command) String() string {
@@ -252,6 +669,19 @@ func (c *Command) String() string {
 	return c.Usage[:ix]
 }
 
+func (c *Command) usage() string {
+	if len(c.Args) == 0 || strings.Contains(strings.TrimSpace(c.Usage), " ") {
+		return c.Usage
+	}
+	str := strings.TrimSpace(c.Usage)
+	var flags bool
+	c.Flag.VisitAll(func(*flag.Flag) { flags = true })
+	if flags {
+		str += " [options]"
+	}
+	return str + " " + argsUsage(c.Args)
+}
+
 func (c *Command) Runnable() bool {
-	return c.Run != nil
+	return c.Run != nil || c.RunResult != nil
 }
@@ -0,0 +1,47 @@
+package cli_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/midbel/cli"
+	"github.com/midbel/cli/clitest"
+)
+
+func TestSizeFlag(t *testing.T) {
+	var (
+		limit = cli.MiB
+		cmd   = cli.Command{
+			Usage: "fetch [-limit size]",
+		}
+	)
+	cmd.Flag.Var(&limit, "limit", "maximum size")
+	cmd.Run = func(c *cli.Command, args []string) error {
+		if err := c.Parse(args); err != nil {
+			return err
+		}
+		fmt.Fprintln(c.Stdout(), limit, limit.Int())
+		return nil
+	}
+	tests := []struct {
+		args []string
+		want string
+		code int
+	}{
+		{args: nil, want: "1MiB 1048576\n"},
+		{args: []string{"-limit", "1.5k"}, want: "1.5KiB 1536\n"},
+		{args: []string{"-limit=2MB"}, want: "2MB 2000000\n"},
+		{args: []string{"-limit", "2XB"}, code: cli.BadExitCode},
+		{args: nil, want: "1MiB 1048576\n"},
+	}
+	for _, tt := range tests {
+		res := clitest.Run(t, &cmd, tt.args...)
+		if res.Code != tt.code {
+			t.Errorf("%q: got exit code %d (%v), want %d", tt.args, res.Code, res.Err, tt.code)
+			continue
+		}
+		if res.Stdout != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.args, res.Stdout, tt.want)
+		}
+	}
+}
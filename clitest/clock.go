@@ -0,0 +1,59 @@
+package clitest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a cli.Clock whose time only changes with Advance. Give it as the
+// Clock of the app under test.
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+type waiter struct {
+	when time.Time
+	c    chan time.Time
+}
+
+// NewClock returns a Clock starting at now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, waiter{when: c.now.Add(d), c: ch})
+	return ch
+}
+
+// Advance moves the clock forward by d, firing the channels returned by
+// After that expire.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	var rest []waiter
+	for _, w := range c.waiters {
+		if w.when.After(c.now) {
+			rest = append(rest, w)
+			continue
+		}
+		w.c <- c.now
+	}
+	c.waiters = rest
+}
@@ -0,0 +1,69 @@
+package clitest
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/midbel/cli"
+)
+
+// Result is the outcome of a command run by Run.
+type Result struct {
+	Stdout string
+	Stderr string
+	Code   int
+	Err    error
+}
+
+// Run executes cmd with args as the only command of an app. The output and
+// the error output of the command are captured and its standard input is
+// empty. Asking for help does not exit the process but gives exit code 2.
+//
+//	tests := []struct {
+//		args []string
+//		want string
+//	}{
+//		{[]string{"-n", "2", "foo"}, "foo\nfoo\n"},
+//	}
+//	for _, tt := range tests {
+//		res := clitest.Run(t, cmd, tt.args...)
+//		if res.Err != nil || res.Stdout != tt.want {
+//			t.Errorf("%v: got %q (%v), want %q", tt.args, res.Stdout, res.Err, tt.want)
+//		}
+//	}
+func Run(t testing.TB, cmd *cli.Command, args ...string) Result {
+	t.Helper()
+	return RunInput(t, cmd, "", args...)
+}
+
+// RunInput is like Run but gives stdin as the standard input of cmd.
+func RunInput(t testing.TB, cmd *cli.Command, stdin string, args ...string) Result {
+	t.Helper()
+	return RunReader(t, cmd, strings.NewReader(stdin), args...)
+}
+
+// RunReader is like Run but reads the standard input of cmd from r.
+func RunReader(t testing.TB, cmd *cli.Command, r io.Reader, args ...string) Result {
+	t.Helper()
+
+	var (
+		stdout bytes.Buffer
+		stderr bytes.Buffer
+		app    = cli.App{
+			Main:        cmd,
+			Stdin:       r,
+			Stdout:      &stdout,
+			Stderr:      &stderr,
+			Interactive: true,
+		}
+	)
+	err := app.Run(args)
+	return Result{
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
+		Code:   cli.ExitCode(err),
+		Err:    err,
+	}
+}
@@ -0,0 +1,254 @@
+package clitest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/midbel/cli"
+)
+
+// Script runs the script found in file against app in a fresh temporary
+// directory. A script is made of one command per line; blank lines and lines
+// starting with # are ignored. Prefixing a command with ! negates it.
+//
+//	run args...     run app with args and check that it succeeds
+//	code n          check the exit code of the last run
+//	stdout regexp   check that the output of the last run matches regexp
+//	stderr regexp   check that the error output of the last run matches regexp
+//	env key=value   set an environment variable
+//	cd dir          change the current directory
+//	exists file     check that file exists
+//
+// Files to create in the temporary directory before running the script can
+// be given at the end of the script, each introduced by a "-- name --" line.
+// $WORK expands to the temporary directory.
+//
+// Script changes the current directory and the environment of the process so
+// it should not be used by parallel tests.
+func Script(t testing.TB, app *cli.App, file string) {
+	t.Helper()
+
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := state{
+		app:  app,
+		work: t.TempDir(),
+		file: filepath.Base(file),
+	}
+	lines, err := s.setup(buf)
+	if err != nil {
+		t.Fatalf("%s: %s", s.file, err)
+	}
+	defer s.restore()
+
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := s.exec(line); err != nil {
+			t.Fatalf("%s:%d: %s: %s", s.file, i+1, line, err)
+		}
+	}
+}
+
+type state struct {
+	app  *cli.App
+	work string
+	file string
+
+	code   int
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+
+	cwd         string
+	env         map[string]*string
+	outw        io.Writer
+	errw        io.Writer
+	interactive bool
+}
+
+func (s *state) setup(buf []byte) ([]string, error) {
+	var (
+		lines []string
+		file  *os.File
+		scan  = bufio.NewScanner(bytes.NewReader(buf))
+	)
+	defer func() {
+		if file != nil {
+			file.Close()
+		}
+	}()
+	for scan.Scan() {
+		line := scan.Text()
+		if name, ok := fileMarker(line); ok {
+			if file != nil {
+				file.Close()
+			}
+			path := filepath.Join(s.work, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return nil, err
+			}
+			f, err := os.Create(path)
+			if err != nil {
+				return nil, err
+			}
+			file = f
+			continue
+		}
+		if file != nil {
+			fmt.Fprintln(file, line)
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scan.Err(); err != nil {
+		return nil, err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chdir(s.work); err != nil {
+		return nil, err
+	}
+	s.cwd = cwd
+	s.env = make(map[string]*string)
+	s.setenv("WORK", s.work)
+
+	s.outw, s.errw, s.interactive = s.app.Stdout, s.app.Stderr, s.app.Interactive
+	s.app.Stdout = &s.stdout
+	s.app.Stderr = &s.stderr
+	s.app.Interactive = true
+	return lines, nil
+}
+
+func (s *state) restore() {
+	s.app.Stdout, s.app.Stderr, s.app.Interactive = s.outw, s.errw, s.interactive
+	for k, v := range s.env {
+		if v == nil {
+			os.Unsetenv(k)
+		} else {
+			os.Setenv(k, *v)
+		}
+	}
+	os.Chdir(s.cwd)
+}
+
+func (s *state) exec(line string) error {
+	negate := strings.HasPrefix(line, "!")
+	if negate {
+		line = strings.TrimSpace(line[1:])
+	}
+	var (
+		cmd  = line
+		rest string
+	)
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		cmd, rest = line[:i], strings.TrimSpace(line[i+1:])
+	}
+	rest = os.ExpandEnv(rest)
+	switch cmd {
+	case "run":
+		return s.run(rest, negate)
+	case "code":
+		return s.checkCode(rest, negate)
+	case "stdout":
+		return match("stdout", rest, s.stdout.String(), negate)
+	case "stderr":
+		return match("stderr", rest, s.stderr.String(), negate)
+	case "env":
+		i := strings.Index(rest, "=")
+		if i <= 0 {
+			return fmt.Errorf("usage: env key=value")
+		}
+		s.setenv(rest[:i], rest[i+1:])
+	case "cd":
+		return os.Chdir(rest)
+	case "exists":
+		_, err := os.Stat(rest)
+		if negate && err == nil {
+			return fmt.Errorf("%s: file exists", rest)
+		}
+		if !negate {
+			return err
+		}
+	default:
+		return fmt.Errorf("%s: unknown command", cmd)
+	}
+	return nil
+}
+
+func (s *state) run(line string, negate bool) error {
+	args, err := cli.Split(line)
+	if err != nil {
+		return err
+	}
+	s.stdout.Reset()
+	s.stderr.Reset()
+	s.code = s.app.Execute(args)
+	if negate && s.code == 0 {
+		return fmt.Errorf("unexpected command success")
+	}
+	if !negate && s.code != 0 {
+		return fmt.Errorf("unexpected command failure (exit code %d)\n%s", s.code, s.stderr.String())
+	}
+	return nil
+}
+
+func (s *state) checkCode(str string, negate bool) error {
+	code, err := strconv.Atoi(str)
+	if err != nil {
+		return fmt.Errorf("%s: invalid exit code", str)
+	}
+	if (code == s.code) == negate {
+		return fmt.Errorf("unexpected exit code %d", s.code)
+	}
+	return nil
+}
+
+func (s *state) setenv(key, value string) {
+	if _, ok := s.env[key]; !ok {
+		if prev, ok := os.LookupEnv(key); ok {
+			s.env[key] = &prev
+		} else {
+			s.env[key] = nil
+		}
+	}
+	os.Setenv(key, value)
+}
+
+func match(what, pattern, output string, negate bool) error {
+	if words, err := cli.Split(pattern); err == nil && len(words) == 1 {
+		pattern = words[0]
+	}
+	re, err := regexp.Compile("(?m)" + pattern)
+	if err != nil {
+		return err
+	}
+	if re.MatchString(output) == negate {
+		if negate {
+			return fmt.Errorf("%s unexpectedly matches %q\n%s", what, pattern, output)
+		}
+		return fmt.Errorf("%s does not match %q\n%s", what, pattern, output)
+	}
+	return nil
+}
+
+func fileMarker(line string) (string, bool) {
+	if !strings.HasPrefix(line, "-- ") || !strings.HasSuffix(line, " --") || len(line) < 7 {
+		return "", false
+	}
+	return strings.TrimSpace(line[3 : len(line)-3]), true
+}
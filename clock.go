@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the source of time of an app. It can be replaced to control the
+// time seen by the commands, in tests for instance.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the Clock of the system.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+type clockKey struct{}
+
+// ClockFrom returns the clock of the app running the command, SystemClock by
+// default. Unlike Now, it is not frozen in deterministic mode, and is the one
+// to use to measure durations and to wait.
+func ClockFrom(ctx context.Context) Clock {
+	if c, ok := ctx.Value(clockKey{}).(Clock); ok {
+		return c
+	}
+	return SystemClock{}
+}
+
+func withClock(ctx context.Context, c Clock) context.Context {
+	if c == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, clockKey{}, c)
+}
+
+// since returns the time elapsed since t on the clock of ctx.
+func since(ctx context.Context, t time.Time) time.Duration {
+	return ClockFrom(ctx).Now().Sub(t)
+}
+
+// sleep waits for d on the clock of ctx, unless ctx is done before.
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ClockFrom(ctx).After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
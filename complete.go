@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const completeCmd = "__complete"
+
+// completeEnv, set to zsh by its completion script, makes the candidates come
+// with their descriptions, translated in the language given by completeLangEnv
+// or else in the one of the locale.
+const (
+	completeEnv     = "CLI_COMPLETE"
+	completeLangEnv = "CLI_COMPLETE_LANG"
+)
+
+// complete prints the candidates for the last of args, the words of a command
+// line after the name of the program, one per line. It is used by the
+// completion scripts of the shells.
+func (a *App) complete(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	var (
+		word = args[len(args)-1]
+		list []string
+		desc = make(map[string]string)
+	)
+	if len(args) == 1 {
+		for _, c := range a.Commands {
+			if c.Runnable() {
+				list = append(list, c.String())
+				list = append(list, c.Alias...)
+				desc[c.String()] = c.Short
+			}
+		}
+	} else if c := a.lookup(args[0]); c != nil {
+		c.prepare()
+		if strings.HasPrefix(word, "-") {
+			c.Flag.VisitAll(func(f *flag.Flag) {
+				list = append(list, "-"+f.Name)
+				desc["-"+f.Name] = f.Usage
+			})
+		} else if c.Complete != nil {
+			list = c.Complete(ctx, args[1:len(args)-1])
+		}
+	}
+	sort.Strings(list)
+	zsh := os.Getenv(completeEnv) == "zsh"
+	lang := os.Getenv(completeLangEnv)
+	if lang == "" {
+		lang = Locale()
+	}
+	for _, s := range list {
+		if !strings.HasPrefix(s, word) {
+			continue
+		}
+		if !zsh {
+			fmt.Fprintln(w, s)
+			continue
+		}
+		d := desc[s]
+		s = strings.ReplaceAll(s, ":", `\:`)
+		if d != "" {
+			s += ":" + TranslateTo(lang, d)
+		}
+		fmt.Fprintln(w, s)
+	}
+	return nil
+}
+
+// completion prints the completion script of the app for the shell given in
+// args. The script of zsh shows the descriptions of the commands and of the
+// flags, in the language given with -lang or else in the one of the locale.
+func (a *App) completion(w io.Writer, args []string) error {
+	var (
+		set  = flag.NewFlagSet("completion", flag.ContinueOnError)
+		lang = set.String("lang", "", "")
+	)
+	set.SetOutput(io.Discard)
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	var (
+		name = a.name()
+		fn   = "_" + strings.NewReplacer("-", "_", ".", "_").Replace(name)
+	)
+	switch shell := set.Arg(0); shell {
+	case "bash", "":
+		fmt.Fprintf(w, bashCompletion, fn, name, completeCmd, fn, name)
+	case "zsh":
+		env := completeEnv + "=zsh"
+		if *lang != "" {
+			env += " " + completeLangEnv + "=" + "'" + strings.ReplaceAll(normalizeLocale(*lang), "'", `'\''`) + "'"
+		}
+		fmt.Fprintf(w, zshCompletion, name, fn, env, name, completeCmd, fn, name)
+	default:
+		return choiceError("shell", shell, []string{"bash", "zsh"})
+	}
+	return nil
+}
+
+const bashCompletion = `%s() {
+	local IFS=$'\n'
+	COMPREPLY=($(%s %s "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F %s %s
+`
+
+const zshCompletion = `#compdef %s
+%s() {
+	local -a list
+	list=(${(f)"$(%s %s %s "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+	_describe 'values' list || _files
+}
+compdef %s %s
+`
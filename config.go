@@ -0,0 +1,259 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// ConfigPaths is a flag value collecting the paths of configuration files. It
+// can be given multiple times.
+type ConfigPaths []string
+
+func (c *ConfigPaths) Set(str string) error {
+	*c = append(*c, ExpandHome(str))
+	return nil
+}
+
+func (c *ConfigPaths) String() string {
+	if c == nil {
+		return ""
+	}
+	return strings.Join(*c, ",")
+}
+
+// Config is the result of the deep merge of JSON configuration files. Its
+// keys are the paths of the values in the files, with the names of nested
+// objects separated by dots.
+type Config struct {
+	values map[string]interface{}
+	origin map[string]string
+	sealed map[string]bool
+}
+
+// ConfigFiles returns, for the application name, the system configuration
+// file, the configuration file of the user and the .name.json files found in
+// the current directory and its parents, the nearest last.
+func ConfigFiles(name string) []string {
+	var files []string
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("ProgramData"); dir != "" {
+			files = append(files, filepath.Join(dir, name, "config.json"))
+		}
+	} else {
+		files = append(files, filepath.Join("/etc", name, "config.json"))
+	}
+	if _, err := os.UserConfigDir(); err == nil {
+		files = append(files, userConfigFile(name))
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return files
+	}
+	var local []string
+	for {
+		file := filepath.Join(dir, "."+name+".json")
+		if i, err := os.Stat(file); err == nil && i.Mode().IsRegular() {
+			local = append(local, file)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	for i := len(local) - 1; i >= 0; i-- {
+		files = append(files, local[i])
+	}
+	return files
+}
+
+// LoadConfig loads and merges the given files, skipping the ones that do not
+// exist. The values of a file override the ones of the files before it.
+func LoadConfig(files ...string) (*Config, error) {
+	cfg := Config{
+		values: make(map[string]interface{}),
+		origin: make(map[string]string),
+		sealed: make(map[string]bool),
+	}
+	for _, file := range files {
+		buf, err := os.ReadFile(file)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		var (
+			values map[string]interface{}
+			dec    = json.NewDecoder(bytes.NewReader(buf))
+		)
+		dec.UseNumber()
+		if err := dec.Decode(&values); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		cfg.merge("", values, file)
+	}
+	return &cfg, nil
+}
+
+func (c *Config) merge(prefix string, values map[string]interface{}, file string) {
+	for k, v := range values {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if obj, ok := v.(map[string]interface{}); ok {
+			c.merge(key, obj, file)
+			continue
+		}
+		c.values[key] = v
+		c.origin[key] = file
+	}
+}
+
+// Lookup returns the value of key. Lists are returned as []string and the
+// other values as a string.
+func (c *Config) Lookup(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	v, ok := c.values[key]
+	if !ok {
+		return nil, false
+	}
+	if list, ok := v.([]interface{}); ok {
+		str := make([]string, len(list))
+		for i := range list {
+			str[i] = configString(list[i])
+		}
+		return str, true
+	}
+	return configString(v), true
+}
+
+// Get returns the value of key as a string. Lists are joined with commas.
+func (c *Config) Get(key string) string {
+	v, _ := c.Lookup(key)
+	switch v := v.(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return ""
+	}
+}
+
+// Origin returns the file that supplied the value of key.
+func (c *Config) Origin(key string) string {
+	if c == nil {
+		return ""
+	}
+	return c.origin[key]
+}
+
+func (c *Config) Keys() []string {
+	if c == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(c.values))
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Trace writes every key with its value and the file that supplied it. The
+// values that were encrypted are redacted.
+func (c *Config) Trace(w io.Writer) {
+	for _, k := range c.Keys() {
+		fmt.Fprintf(w, "%s = %s (%s)\n", k, c.display(k), c.Origin(k))
+	}
+}
+
+func (c *Config) display(key string) string {
+	if c.sealed[key] {
+		return redacted
+	}
+	return c.Get(key)
+}
+
+// Apply sets the flags of set that have a value in the configuration and
+// returns their names. The value of a flag is looked up under section.name
+// first, then under name. Lists set the flag once per element.
+func (c *Config) Apply(set *flag.FlagSet, section string) ([]string, error) {
+	if c == nil {
+		return nil, nil
+	}
+	var (
+		names []string
+		err   error
+	)
+	set.VisitAll(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		key := c.flagKey(section, f.Name)
+		v, ok := c.Lookup(key)
+		if !ok {
+			return
+		}
+		list, ok := v.([]string)
+		if !ok {
+			list = []string{v.(string)}
+		}
+		for _, str := range list {
+			if err = f.Value.Set(str); err != nil {
+				err = fmt.Errorf("%s: invalid value %q for %s: %w", c.Origin(key), str, key, err)
+				return
+			}
+		}
+		names = append(names, f.Name)
+	})
+	return names, err
+}
+
+// flagKey returns the key of the flag name of the given section.
+func (c *Config) flagKey(section, name string) string {
+	if section != "" {
+		if _, ok := c.values[section+"."+name]; ok {
+			return section + "." + name
+		}
+	}
+	return name
+}
+
+func configString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+type configKey struct{}
+
+// ConfigFrom returns the configuration loaded by the app running the command.
+func ConfigFrom(ctx context.Context) *Config {
+	cfg, _ := ctx.Value(configKey{}).(*Config)
+	return cfg
+}
+
+func withConfig(ctx context.Context, cfg *Config) context.Context {
+	return context.WithValue(ctx, configKey{}, cfg)
+}
@@ -0,0 +1,352 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConfigField describes a key of the configuration.
+type ConfigField struct {
+	Key  string
+	Desc string
+	// Values, when not empty, are the only values accepted for the key.
+	Values []string
+	// Sensitive values are encrypted in the configuration files.
+	Sensitive bool
+	// List is set for the keys of the flags that can be given several times,
+	// whose value is a list. config set splits its value on commas for them.
+	List bool
+}
+
+type ConfigSchema []ConfigField
+
+func (s ConfigSchema) Lookup(key string) (ConfigField, bool) {
+	for _, f := range s {
+		if f.Key == key {
+			return f, true
+		}
+	}
+	return ConfigField{}, false
+}
+
+func (s ConfigSchema) keys() []string {
+	keys := make([]string, len(s))
+	for i := range s {
+		keys[i] = s[i].Key
+	}
+	return keys
+}
+
+const (
+	sealedPrefix  = "enc:v1:"
+	configKeyName = "config-key"
+)
+
+func sealValue(key []byte, name, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	buf := gcm.Seal(nonce, nonce, []byte(value), []byte(name))
+	return sealedPrefix + base64.StdEncoding.EncodeToString(buf), nil
+}
+
+func openValue(key []byte, name, value string) (string, error) {
+	buf, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, sealedPrefix))
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(buf) < gcm.NonceSize() {
+		return "", fmt.Errorf("encrypted value too short")
+	}
+	nonce, buf := buf[:gcm.NonceSize()], buf[gcm.NonceSize():]
+	buf, err = gcm.Open(nil, nonce, buf, []byte(name))
+	return string(buf), err
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// configSecret returns the key encrypting the sensitive values, kept in store.
+// If create is set, a new key is generated when store does not have one yet.
+func configSecret(store CredentialStore, create bool) ([]byte, error) {
+	str, err := store.Get(configKeyName)
+	if err == nil {
+		return base64.StdEncoding.DecodeString(str)
+	}
+	if !errors.Is(err, ErrNoCredentials) || !create {
+		return nil, err
+	}
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, store.Set(configKeyName, base64.StdEncoding.EncodeToString(key))
+}
+
+// unseal decrypts the encrypted values of c. The key is only retrieved from
+// store when c has encrypted values.
+func (c *Config) unseal(store CredentialStore) error {
+	var key []byte
+	for k, v := range c.values {
+		str, ok := v.(string)
+		if !ok || !strings.HasPrefix(str, sealedPrefix) {
+			continue
+		}
+		if key == nil {
+			var err error
+			if key, err = configSecret(store, false); err != nil {
+				return fmt.Errorf("%s: can not decrypt %s: %w", c.Origin(k), k, err)
+			}
+		}
+		str, err := openValue(key, k, str)
+		if err != nil {
+			return fmt.Errorf("%s: can not decrypt %s: %w", c.Origin(k), k, err)
+		}
+		c.values[k] = str
+		c.sealed[k] = true
+	}
+	return nil
+}
+
+// ConfigCommand returns a builtin command editing the configuration file of
+// the user of the app:
+//
+//	config list
+//	config get <key>
+//	config set [-secret] <key> [<value>]
+//	config unset <key>
+//	config schema
+//
+// Schema prints the JSON Schema of the configuration files, for the editors.
+// When App.Schema is not empty, only its keys can be set. The values of the
+// keys marked as sensitive, or set with -secret, are encrypted with a key kept
+// in App.Keyring and decrypted when the configuration is loaded. When no
+// value is given for them, set asks for it on the terminal.
+func ConfigCommand() *Command {
+	var (
+		secret bool
+		cmd    = Command{
+			Usage: "config <list|get|set|unset|schema> [-secret] [<key>] [<value>]",
+			Short: "show and edit the configuration",
+		}
+	)
+	cmd.Flag.BoolVar(&secret, "secret", false, "encrypt the value")
+	cmd.Complete = completeConfig
+	cmd.Run = func(c *Command, args []string) error {
+		if err := c.Parse(args); err != nil {
+			return err
+		}
+		action := c.Flag.Arg(0)
+		if c.Flag.NArg() > 0 {
+			if err := c.Flag.Parse(c.Flag.Args()[1:]); err != nil {
+				return err
+			}
+		}
+		var (
+			a   = appFrom(c.Context())
+			cfg = ConfigFrom(c.Context())
+			key = c.Flag.Arg(0)
+		)
+		if a == nil || a.Config == "" {
+			return fmt.Errorf("config: application without configuration")
+		}
+		if action != "list" && action != "schema" && action != "" && key == "" {
+			return fmt.Errorf("config %s: missing key", action)
+		}
+		switch action {
+		case "list", "":
+			for _, k := range cfg.Keys() {
+				fmt.Fprintf(c.Stdout(), "%s = %s\n", k, cfg.display(k))
+			}
+			return nil
+		case "get":
+			v, ok := cfg.Lookup(key)
+			if !ok {
+				return fmt.Errorf("%s: key not set", key)
+			}
+			if list, ok := v.([]string); ok {
+				v = strings.Join(list, ",")
+			}
+			fmt.Fprintln(c.Stdout(), v)
+			return nil
+		case "set":
+			f, ok := a.Schema.Lookup(key)
+			if !ok && len(a.Schema) > 0 {
+				return choiceError("key", key, a.Schema.keys())
+			}
+			var (
+				seal  = secret || f.Sensitive
+				value = c.Flag.Arg(1)
+			)
+			if c.Flag.NArg() < 2 {
+				if !seal {
+					return fmt.Errorf("config set: missing value")
+				}
+				str, err := readSecret(c.Context(), c.Stdin(), c.Stderr(), fmt.Sprintf("value of %s: ", key))
+				if err != nil {
+					return err
+				}
+				value = str
+			}
+			if value == "" {
+				return fmt.Errorf("config set: empty value")
+			}
+			if !f.List {
+				if len(f.Values) > 0 && !containsString(f.Values, value) {
+					return choiceError("value for "+key, value, f.Values)
+				}
+				return setConfig(c.Context(), a, key, value, seal)
+			}
+			if seal {
+				return fmt.Errorf("config set: %s: lists can not be encrypted", key)
+			}
+			list := strings.Split(value, ",")
+			for _, v := range list {
+				if len(f.Values) > 0 && !containsString(f.Values, v) {
+					return choiceError("value for "+key, v, f.Values)
+				}
+			}
+			return setConfig(c.Context(), a, key, list, false)
+		case "unset":
+			return setConfig(c.Context(), a, key, "", false)
+		case "schema":
+			return writeSchema(c.Stdout(), a.Schema.JSONSchema())
+		default:
+			return fmt.Errorf("%s: unknown action", action)
+		}
+	}
+	return &cmd
+}
+
+// completeConfig completes the actions of the config command, the keys of
+// the schema, or the keys set when there is none, and the values of the keys
+// having a fixed set of values.
+func completeConfig(ctx context.Context, args []string) []string {
+	var words []string
+	for _, a := range args {
+		if !strings.HasPrefix(a, "-") {
+			words = append(words, a)
+		}
+	}
+	a := appFrom(ctx)
+	if a == nil {
+		return nil
+	}
+	switch len(words) {
+	case 0:
+		return []string{"list", "get", "set", "unset", "schema"}
+	case 1:
+		if words[0] == "list" || words[0] == "schema" {
+			return nil
+		}
+		if len(a.Schema) > 0 && words[0] == "set" {
+			return a.Schema.keys()
+		}
+		return ConfigFrom(ctx).Keys()
+	case 2:
+		if f, ok := a.Schema.Lookup(words[1]); ok && words[0] == "set" {
+			return f.Values
+		}
+	}
+	return nil
+}
+
+// setConfig sets key to value in the configuration file of the user. An empty
+// value removes the key.
+// setConfig sets key to value, a string or a list of strings, in the
+// configuration file of the user. An empty value removes key.
+func setConfig(ctx context.Context, a *App, key string, value interface{}, secret bool) error {
+	if str, ok := value.(string); ok && secret && str != "" {
+		k, err := configSecret(a.keyring(), !DryRun(ctx))
+		if err != nil && !DryRun(ctx) {
+			return err
+		}
+		if k != nil {
+			if value, err = sealValue(k, key, str); err != nil {
+				return err
+			}
+		}
+	}
+	file := userConfigFile(a.Config)
+	values := make(map[string]interface{})
+	buf, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if len(buf) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(buf))
+		dec.UseNumber()
+		if err := dec.Decode(&values); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	var (
+		parts = strings.Split(key, ".")
+		obj   = values
+	)
+	for _, p := range parts[:len(parts)-1] {
+		sub, ok := obj[p].(map[string]interface{})
+		if !ok {
+			sub = make(map[string]interface{})
+			obj[p] = sub
+		}
+		obj = sub
+	}
+	last := parts[len(parts)-1]
+	if value == "" {
+		if _, ok := obj[last]; !ok {
+			return fmt.Errorf("%s: key not set in %s", key, file)
+		}
+		delete(obj, last)
+	} else {
+		obj[last] = value
+	}
+	old := buf
+	if buf, err = json.MarshalIndent(values, "", "  "); err != nil {
+		return err
+	}
+	buf = EOL(ctx).Convert(append(buf, '\n'))
+	if w, ok := ctx.Value(dryRunKey{}).(io.Writer); ok {
+		Diff(ctx, w, file, file, old, buf)
+	}
+	return Do(ctx, fmt.Sprintf("write %s", file), func() error {
+		if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+			return err
+		}
+		return WriteFileBackup(file, buf, 0600)
+	})
+}
+
+func userConfigFile(name string) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, name, "config.json")
+}
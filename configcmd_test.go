@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSealValue(t *testing.T) {
+	var (
+		key   = []byte(strings.Repeat("k", 32))
+		other = []byte(strings.Repeat("o", 32))
+	)
+	sealed, err := sealValue(key, "db.password", "s3cret")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.HasPrefix(sealed, sealedPrefix) || strings.Contains(sealed, "s3cret") {
+		t.Fatalf("%s: value not sealed", sealed)
+	}
+	again, _ := sealValue(key, "db.password", "s3cret")
+	if again == sealed {
+		t.Errorf("sealing twice gives the same value")
+	}
+	tests := []struct {
+		name  string
+		key   []byte
+		value string
+		err   bool
+	}{
+		{name: "db.password", key: key, value: sealed},
+		{name: "db.password", key: other, value: sealed, err: true},
+		{name: "api.token", key: key, value: sealed, err: true},
+		{name: "db.password", key: key, value: sealed[:len(sealed)-4] + "AAA=", err: true},
+		{name: "db.password", key: key, value: sealedPrefix + "AAAA", err: true},
+		{name: "db.password", key: key, value: sealedPrefix + "!!", err: true},
+		{name: "db.password", key: key[:5], value: sealed, err: true},
+	}
+	for i, tt := range tests {
+		got, err := openValue(tt.key, tt.name, tt.value)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%d: expected error, got %q", i, got)
+			}
+			continue
+		}
+		if err != nil || got != "s3cret" {
+			t.Errorf("%d: got %q (%v), want s3cret", i, got, err)
+		}
+	}
+}
+
+func TestConfigUnseal(t *testing.T) {
+	var (
+		dir   = t.TempDir()
+		store = FileStore{Path: filepath.Join(dir, "credentials.json")}
+	)
+	if _, err := configSecret(&store, false); !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("got %v, want ErrNoCredentials without key", err)
+	}
+	key, err := configSecret(&store, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if again, err := configSecret(&store, true); err != nil || string(again) != string(key) {
+		t.Fatalf("the key changed when asked again (%v)", err)
+	}
+	sealed, err := sealValue(key, "db.password", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "config.json")
+	doc := `{"db": {"password": "` + sealed + `", "user": "admin"}}`
+	if err := os.WriteFile(file, []byte(doc), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.unseal(&store); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tests := []struct {
+		key     string
+		value   string
+		display string
+	}{
+		{key: "db.password", value: "s3cret", display: redacted},
+		{key: "db.user", value: "admin", display: "admin"},
+	}
+	for _, tt := range tests {
+		if got := cfg.Get(tt.key); got != tt.value {
+			t.Errorf("%s: got %q, want %q", tt.key, got, tt.value)
+		}
+		if got := cfg.display(tt.key); got != tt.display {
+			t.Errorf("%s: displayed %q, want %q", tt.key, got, tt.display)
+		}
+	}
+
+	cfg, _ = LoadConfig(file)
+	empty := FileStore{Path: filepath.Join(dir, "none.json")}
+	if err := cfg.unseal(&empty); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("got %v, want ErrNoCredentials without the key", err)
+	}
+}
+
+func TestJSONSchemaList(t *testing.T) {
+	schema := ConfigSchema{
+		{Key: "log.level", Values: []string{"debug", "info"}},
+		{Key: "exclude", Desc: "patterns of the files to skip", List: true},
+	}.JSONSchema()
+	props := schema["properties"].(map[string]interface{})
+	exclude := props["exclude"].(map[string]interface{})
+	if exclude["type"] != "array" || exclude["description"] == nil {
+		t.Errorf("exclude: got %v, want an array with a description", exclude)
+	}
+	if items, ok := exclude["items"].(map[string]interface{}); !ok || items["description"] != nil {
+		t.Errorf("exclude: got items %v, want the schema of a value", exclude["items"])
+	}
+	level := props["log"].(map[string]interface{})["properties"].(map[string]interface{})["level"].(map[string]interface{})
+	if level["type"] != "string" {
+		t.Errorf("log.level: got type %v, want string", level["type"])
+	}
+}
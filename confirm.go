@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// confirm asks the user to confirm the dangerous flags given, seen holding
+// the names of the flags set. Without terminal to ask or in plain mode, where
+// the output usually ends up in logs, -yes is required.
+func (c *Command) confirm(seen map[string]bool) error {
+	if c.yes || DryRun(c.Context()) {
+		return nil
+	}
+	var given []string
+	for _, name := range c.Dangerous {
+		if seen[name] {
+			given = append(given, "-"+name)
+		}
+	}
+	if len(given) == 0 {
+		return nil
+	}
+	flags := strings.Join(given, ", ")
+	if f, ok := c.Stdin().(*os.File); !ok || !IsTerminal(f) || Plain(c.Context()) {
+		return fmt.Errorf("%s: confirmation required, use -yes to proceed", flags)
+	}
+	want := c.String()
+	if c.Confirm != nil {
+		want = c.Confirm(c.Flag.Args())
+	}
+	w := c.Stderr()
+	Message(c.Context(), w, Warning, "%s can not be undone.", flags)
+	fmt.Fprintf(w, "Type %q to continue: ", want)
+	got, err := readLine(c.Stdin())
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(got) != want {
+		return fmt.Errorf("%s: not confirmed", flags)
+	}
+	return nil
+}
+
+// applyConfig sets the flags of c from cfg and returns their names. The -yes
+// flag of the dangerous commands is only taken from the configuration file of
+// the user, not from the project files that anyone can write.
+func (c *Command) applyConfig(cfg *Config) ([]string, error) {
+	applied, err := cfg.Apply(&c.Flag, c.String())
+	if err != nil || len(c.Dangerous) == 0 {
+		return applied, err
+	}
+	a := appFrom(c.parent)
+	for i, name := range applied {
+		if name != "yes" {
+			continue
+		}
+		if a != nil && a.Config != "" && cfg.Origin(cfg.flagKey(c.String(), name)) == userConfigFile(a.Config) {
+			break
+		}
+		if f := c.Flag.Lookup(name); f != nil {
+			f.Value.Set(f.DefValue)
+		}
+		applied = append(applied[:i], applied[i+1:]...)
+		break
+	}
+	return applied, nil
+}
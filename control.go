@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotRunning     = errors.New("not running")
+	ErrAlreadyRunning = errors.New("already running")
+)
+
+type ControlFunc func(ctx context.Context, args []string) (string, error)
+
+// Control is a control socket through which a long running command can be
+// queried or told to reload or stop by another process, usually the commands
+// returned by Commands. The socket is a unix domain socket on every platform.
+//
+// Windows has them since Windows 10 1803 only, Listen failing on the older
+// versions: named pipes are not supported. There, the socket is protected by
+// the access rights of its directory, that Listen does not check, the default
+// directory being in the temporary directory of the user.
+//
+// Besides the handlers registered with Handle, the socket always answers to
+// status and stop. Unless replaced, status reports the pid of the process and
+// since when it listens. stop cancels the context returned by Listen.
+type Control struct {
+	Path string
+
+	mu       sync.Mutex
+	handlers map[string]ControlFunc
+}
+
+// DefaultControl returns a Control listening in the runtime directory of the
+// user or, if there is none, in a directory of the temporary directory only
+// accessible by the user.
+func DefaultControl() *Control {
+	var (
+		name = programName()
+		dir  = os.Getenv("XDG_RUNTIME_DIR")
+	)
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), name)
+		if uid := os.Getuid(); uid >= 0 {
+			dir = fmt.Sprintf("%s-%d", dir, uid)
+		}
+	}
+	return &Control{
+		Path: filepath.Join(dir, name+".sock"),
+	}
+}
+
+func (c *Control) Handle(name string, fn ControlFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.handlers == nil {
+		c.handlers = make(map[string]ControlFunc)
+	}
+	c.handlers[name] = fn
+}
+
+// Listen opens the socket and serves the requests in the background. The
+// returned context is cancelled when ctx is or when stop is received. The
+// socket is removed once the returned context is done. The directory of the
+// socket is created if needed and must not be accessible by the other users,
+// since anyone able to reach the socket controls the process.
+func (c *Control) Listen(ctx context.Context) (context.Context, error) {
+	if err := privateDir(filepath.Dir(c.Path)); err != nil {
+		return nil, err
+	}
+	if err := c.clean(); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", c.Path)
+	if err != nil {
+		if runtime.GOOS == "windows" {
+			err = fmt.Errorf("%w (unix domain sockets need Windows 10 1803 or later)", err)
+		}
+		return nil, err
+	}
+	os.Chmod(c.Path, 0600)
+
+	ctx, cancel := context.WithCancel(ctx)
+	since := ClockFrom(ctx).Now()
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+		os.Remove(c.Path)
+	}()
+	go func() {
+		defer cancel()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go c.serve(ctx, conn, since, cancel)
+		}
+	}()
+	return ctx, nil
+}
+
+// Send sends cmd with its arguments to the process listening on the socket
+// and returns its reply.
+func (c *Control) Send(ctx context.Context, cmd string, args ...string) (string, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", c.Path)
+	if err != nil {
+		var oe *net.OpError
+		if errors.As(err, &oe) && oe.Op == "dial" && !oe.Timeout() {
+			err = ErrNotRunning
+		}
+		return "", err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	req := controlRequest{
+		Cmd:  cmd,
+		Args: args,
+	}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return "", err
+	}
+	var res controlResponse
+	if err := json.NewDecoder(conn).Decode(&res); err != nil {
+		return "", err
+	}
+	if res.Err != "" {
+		return res.Result, errors.New(res.Err)
+	}
+	return res.Result, nil
+}
+
+// Commands returns the status, stop and reload commands talking to the
+// process listening on the socket.
+func (c *Control) Commands() []*Command {
+	return []*Command{
+		c.command("status", "show the status of the running process"),
+		c.command("stop", "stop the running process"),
+		c.command("reload", "reload the running process"),
+	}
+}
+
+func (c *Control) command(name, short string) *Command {
+	cmd := Command{
+		Usage: name,
+		Short: short,
+	}
+	cmd.Run = func(cmd *Command, args []string) error {
+		if err := cmd.Parse(args); err != nil {
+			return err
+		}
+		res, err := c.Send(cmd.Context(), name, cmd.Flag.Args()...)
+		if err != nil {
+			return err
+		}
+		if res != "" {
+			fmt.Fprintln(cmd.Stdout(), res)
+		}
+		return nil
+	}
+	return &cmd
+}
+
+type controlRequest struct {
+	Cmd  string   `json:"cmd"`
+	Args []string `json:"args,omitempty"`
+}
+
+type controlResponse struct {
+	Result string `json:"result,omitempty"`
+	Err    string `json:"error,omitempty"`
+}
+
+func (c *Control) serve(ctx context.Context, conn net.Conn, since time.Time, stop context.CancelFunc) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Minute))
+
+	var (
+		req controlRequest
+		res controlResponse
+	)
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return
+	}
+	c.mu.Lock()
+	fn, ok := c.handlers[req.Cmd]
+	c.mu.Unlock()
+
+	var err error
+	switch {
+	case ok:
+		res.Result, err = fn(ctx, req.Args)
+	case req.Cmd == "status":
+		res.Result = fmt.Sprintf("running (pid %d) since %s", os.Getpid(), since.Format(time.RFC3339))
+	case req.Cmd == "stop":
+	default:
+		err = fmt.Errorf("%s: unknown command (expected %s)", req.Cmd, strings.Join(c.names(), ", "))
+	}
+	if err != nil {
+		res.Err = err.Error()
+	}
+	json.NewEncoder(conn).Encode(res)
+	if req.Cmd == "stop" && err == nil {
+		stop()
+	}
+}
+
+func (c *Control) names() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := []string{"status", "stop"}
+	for n := range c.handlers {
+		if n != "status" && n != "stop" {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names[2:])
+	return names
+}
+
+// clean removes the socket left by a process that did not exit properly.
+// privateDir creates dir, accessible only by the user, or checks that it is
+// when it exists. Permissions are not checked on Windows, where the mode of
+// the files does not reflect their access rights.
+func privateDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	i, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !i.IsDir() {
+		return fmt.Errorf("%s: not a directory", dir)
+	}
+	if runtime.GOOS != "windows" && i.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("%s: directory accessible by other users (%s)", dir, i.Mode().Perm())
+	}
+	return nil
+}
+
+func (c *Control) clean() error {
+	if _, err := os.Stat(c.Path); err != nil {
+		return nil
+	}
+	conn, err := net.DialTimeout("unix", c.Path, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("%s: %w", c.Path, ErrAlreadyRunning)
+	}
+	return os.Remove(c.Path)
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"flag"
+	"strconv"
+	"strings"
+)
+
+// Counter is a flag value incremented each time the flag is given on the
+// command line. An explicit value can still be given with -flag=n.
+type Counter int
+
+// CounterVar registers c in set under name. For single letter names, the
+// stacked forms (-vv, -vvv) are also registered and add as many times to c.
+func CounterVar(set *flag.FlagSet, c *Counter, name, usage string) {
+	set.Var(c, name, usage)
+	if len(name) != 1 {
+		return
+	}
+	for i := 2; i <= 3; i++ {
+		set.Var(counterStep{Counter: c, step: i}, strings.Repeat(name, i), usage)
+	}
+}
+
+func (c *Counter) Set(str string) error {
+	switch str {
+	case "true":
+		*c++
+	case "false":
+		*c = 0
+	default:
+		n, err := strconv.Atoi(str)
+		if err != nil {
+			return err
+		}
+		*c = Counter(n)
+	}
+	return nil
+}
+
+func (c *Counter) String() string {
+	if c == nil {
+		return "0"
+	}
+	return strconv.Itoa(int(*c))
+}
+
+func (c *Counter) IsBoolFlag() bool {
+	return true
+}
+
+func (c *Counter) Type() string {
+	return "counter"
+}
+
+func (c Counter) Int() int {
+	return int(c)
+}
+
+type counterStep struct {
+	*Counter
+	step int
+}
+
+func (c counterStep) Set(str string) error {
+	if str == "true" {
+		*c.Counter += Counter(c.step)
+		return nil
+	}
+	return c.Counter.Set(str)
+}
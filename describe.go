@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+)
+
+type Spec struct {
+	Name     string        `json:"name"`
+	Version  string        `json:"version,omitempty"`
+	Lang     string        `json:"lang,omitempty"`
+	Commands []CommandSpec `json:"commands"`
+}
+
+type CommandSpec struct {
+	Name     string     `json:"name"`
+	Alias    []string   `json:"alias,omitempty"`
+	Usage    string     `json:"usage"`
+	Short    string     `json:"short,omitempty"`
+	Desc     string     `json:"description,omitempty"`
+	Default  bool       `json:"default,omitempty"`
+	Flags    []FlagSpec `json:"flags,omitempty"`
+	Args     []ArgSpec  `json:"args,omitempty"`
+	Examples []Example  `json:"examples,omitempty"`
+}
+
+type ArgSpec struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Optional bool   `json:"optional,omitempty"`
+	Variadic bool   `json:"variadic,omitempty"`
+}
+
+type FlagSpec struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Default string `json:"default,omitempty"`
+	Usage   string `json:"usage,omitempty"`
+}
+
+// Describe gives a machine readable description of the given commands and of
+// their flags.
+func Describe(cs []*Command) Spec {
+	spec := Spec{
+		Name:    programName(),
+		Version: Version,
+	}
+	for _, c := range cs {
+		if !c.Runnable() {
+			continue
+		}
+		spec.Commands = append(spec.Commands, c.describe())
+	}
+	return spec
+}
+
+func (a *App) describe(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	e.SetEscapeHTML(false)
+	return e.Encode(a.spec())
+}
+
+// WriteDocs writes the reference of the commands of a in dir, translated for
+// lang when it is not empty. See WriteDocs.
+func (a *App) WriteDocs(dir string, format DocsFormat, lang string) error {
+	return WriteDocs(dir, a.docsSpec(lang), format)
+}
+
+func (a *App) docsSpec(lang string) Spec {
+	spec := a.spec()
+	if lang != "" {
+		spec = spec.Translate(lang)
+	}
+	return spec
+}
+
+func (a *App) spec() Spec {
+	spec := Describe(a.Commands)
+	if a.Info.Name != "" {
+		spec.Name = a.Info.Name
+	}
+	if a.Info.Version != "" {
+		spec.Version = a.Info.Version
+	}
+	return spec
+}
+
+func (c *Command) describe() CommandSpec {
+	c.prepare()
+	spec := CommandSpec{
+		Name:     c.String(),
+		Alias:    c.Alias,
+		Usage:    c.usage(),
+		Short:    c.Short,
+		Desc:     strings.TrimSpace(c.Desc),
+		Default:  c.Default,
+		Examples: c.Examples,
+	}
+	c.Flag.VisitAll(func(f *flag.Flag) {
+		_, usage := flag.UnquoteUsage(f)
+		spec.Flags = append(spec.Flags, FlagSpec{
+			Name:    f.Name,
+			Type:    flagType(f),
+			Default: f.DefValue,
+			Usage:   usage,
+		})
+	})
+	for _, a := range c.Args {
+		typ := a.Type.Name
+		if typ == "" {
+			typ = String.Name
+		}
+		spec.Args = append(spec.Args, ArgSpec{
+			Name:     a.Name,
+			Type:     typ,
+			Optional: a.Optional,
+			Variadic: a.Variadic,
+		})
+	}
+	return spec
+}
+
+func flagType(f *flag.Flag) string {
+	if t, ok := f.Value.(interface{ Type() string }); ok {
+		return t.Type()
+	}
+	if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+		return "bool"
+	}
+	name, _ := flag.UnquoteUsage(f)
+	if name != "value" {
+		return name
+	}
+	typ := reflect.Indirect(reflect.ValueOf(f.Value)).Type()
+	if n := strings.TrimSuffix(typ.Name(), "Value"); n != "" {
+		name = strings.ToLower(n)
+	}
+	return name
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+)
+
+type deterministicKey struct{}
+
+// Deterministic reports whether the command runs in deterministic mode, either
+// because the --deterministic flag was given or because SOURCE_DATE_EPOCH is
+// set. In this mode, commands should avoid any output that could change from
+// one run to another: timestamps, progress, colors or unordered listings.
+func Deterministic(ctx context.Context) bool {
+	_, ok := ctx.Value(deterministicKey{}).(time.Time)
+	return ok
+}
+
+// Now returns the current time on the clock of the app or, in deterministic
+// mode, the time given by SOURCE_DATE_EPOCH (or the unix epoch if not set).
+func Now(ctx context.Context) time.Time {
+	if t, ok := ctx.Value(deterministicKey{}).(time.Time); ok {
+		return t
+	}
+	return ClockFrom(ctx).Now()
+}
+
+func withDeterministic(ctx context.Context) context.Context {
+	var when int64
+	if n, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		when = n
+	}
+	return context.WithValue(ctx, deterministicKey{}, time.Unix(when, 0).UTC())
+}
@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	ErrAccessDenied = errors.New("access denied by user")
+	ErrCodeExpired  = errors.New("device code expired")
+)
+
+const deviceGrant = "urn:ietf:params:oauth:grant-type:device_code"
+
+type DeviceConfig struct {
+	ClientID  string
+	Scopes    []string
+	DeviceURL string
+	TokenURL  string
+
+	Client *http.Client
+	// Output receives the instructions for the user. Defaults to stderr.
+	Output    io.Writer
+	NoBrowser bool
+}
+
+type OAuthToken struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	Scope        string `json:"scope"`
+}
+
+type deviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	VerificationURL string `json:"verification_url"`
+	CompleteURI     string `json:"verification_uri_complete"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+type oauthError struct {
+	Code string `json:"error"`
+	Desc string `json:"error_description"`
+}
+
+func (e oauthError) Error() string {
+	if e.Desc != "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Desc)
+	}
+	return e.Code
+}
+
+// DeviceFlow obtains a token with the OAuth2 device authorization grant (RFC
+// 8628). The user code is displayed and the verification page opened in the
+// browser, then the token endpoint is polled until the user approves or
+// denies the request, or until the code expires.
+func DeviceFlow(ctx context.Context, cfg DeviceConfig) (OAuthToken, error) {
+	var (
+		token OAuthToken
+		code  deviceCode
+		out   = cfg.Output
+	)
+	if out == nil {
+		out = os.Stderr
+	}
+	vs := url.Values{}
+	vs.Set("client_id", cfg.ClientID)
+	if len(cfg.Scopes) > 0 {
+		vs.Set("scope", strings.Join(cfg.Scopes, " "))
+	}
+	if err := cfg.post(ctx, cfg.DeviceURL, vs, &code); err != nil {
+		return token, err
+	}
+	verify := code.VerificationURI
+	if verify == "" {
+		verify = code.VerificationURL
+	}
+	fmt.Fprintf(out, "open %s and enter the code: %s\n", verify, code.UserCode)
+	if !cfg.NoBrowser {
+		target := code.CompleteURI
+		if target == "" {
+			target = verify
+		}
+		OpenBrowser(target)
+	}
+
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	clock := ClockFrom(ctx)
+	expires := clock.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	if code.ExpiresIn <= 0 {
+		expires = clock.Now().Add(15 * time.Minute)
+	}
+
+	vs = url.Values{}
+	vs.Set("grant_type", deviceGrant)
+	vs.Set("device_code", code.DeviceCode)
+	vs.Set("client_id", cfg.ClientID)
+	defer fmt.Fprintln(out)
+	plain := Plain(ctx)
+	if plain {
+		fmt.Fprintln(out, "waiting for authorization...")
+	}
+	for {
+		left := expires.Sub(clock.Now())
+		if left <= 0 {
+			return token, ErrCodeExpired
+		}
+		if !plain {
+			fmt.Fprintf(out, "\rwaiting for authorization (expires in %s)... ", left.Round(time.Second))
+		}
+		select {
+		case <-ctx.Done():
+			return token, ctx.Err()
+		case <-clock.After(interval):
+		}
+		err := cfg.post(ctx, cfg.TokenURL, vs, &token)
+		if err == nil {
+			if !plain {
+				fmt.Fprint(out, "\r")
+			}
+			fmt.Fprint(out, "authorization granted")
+			return token, nil
+		}
+		var oe oauthError
+		if !errors.As(err, &oe) {
+			return token, err
+		}
+		switch oe.Code {
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		case "access_denied":
+			return token, ErrAccessDenied
+		case "expired_token":
+			return token, ErrCodeExpired
+		default:
+			return token, err
+		}
+	}
+}
+
+func (cfg DeviceConfig) post(ctx context.Context, endpoint string, vs url.Values, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(vs.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	client := cfg.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	buf, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	var oe oauthError
+	if err := json.Unmarshal(buf, &oe); err == nil && oe.Code != "" {
+		return oe
+	}
+	if res.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s: unexpected status %s", endpoint, res.Status)
+	}
+	return json.Unmarshal(buf, v)
+}
@@ -0,0 +1,383 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const diffContext = 3
+
+type diffOp struct {
+	kind byte
+	line string
+	// positions of the line in the old and new texts.
+	i, j int
+}
+
+// Diff writes on w the unified diff turning a into b, from and to being their
+// names in the header. Nothing is written when a and b are equal. The lines
+// are colored like Message does, with the colors of the theme of ctx.
+func Diff(ctx context.Context, w io.Writer, from, to string, a, b []byte) error {
+	ops := diffLines(splitLines(a), splitLines(b))
+	hunks := diffHunks(ops)
+	if len(hunks) == 0 {
+		return nil
+	}
+	w = chatter(ctx, w)
+	var (
+		theme = ThemeFrom(ctx)
+		color = useColor(ctx, w)
+		buf   bytes.Buffer
+	)
+	paint := func(code, str string) {
+		if color && code != "" {
+			str = "\033[" + code + "m" + str + "\033[0m"
+		}
+		buf.WriteString(str)
+		buf.WriteString("\n")
+	}
+	paint("1", "--- "+from)
+	paint("1", "+++ "+to)
+	for _, h := range hunks {
+		paint(theme.Styles[Info].Color, hunkHeader(h))
+		for _, op := range h {
+			code := ""
+			switch op.kind {
+			case '-':
+				code = theme.Styles[Failure].Color
+			case '+':
+				code = theme.Styles[Success].Color
+			}
+			line := strings.TrimSuffix(op.line, "\n")
+			paint(code, string(op.kind)+line)
+			if !strings.HasSuffix(op.line, "\n") {
+				buf.WriteString("\\ No newline at end of file\n")
+			}
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// ApplyPatch applies the hunks of the unified diff patch to src. A hunk whose
+// lines are not found where expected is searched in the rest of src.
+func ApplyPatch(src, patch []byte) ([]byte, error) {
+	var (
+		lines = splitLines(src)
+		out   []string
+		pos   int
+		delta int
+	)
+	hunks, err := parsePatch(patch)
+	if err != nil {
+		return nil, err
+	}
+	for n, h := range hunks {
+		at := h.start + delta
+		if !h.matchAt(lines, at) || at < pos {
+			at = -1
+			for i := pos; i+len(h.old) <= len(lines); i++ {
+				if h.matchAt(lines, i) {
+					at = i
+					break
+				}
+			}
+		}
+		if at < 0 {
+			return nil, fmt.Errorf("hunk #%d does not apply", n+1)
+		}
+		out = append(out, lines[pos:at]...)
+		out = append(out, h.new...)
+		pos = at + len(h.old)
+		delta = at - h.start
+	}
+	out = append(out, lines[pos:]...)
+	return []byte(strings.Join(out, "")), nil
+}
+
+// PatchFile applies patch to file, unless in dry-run mode.
+func PatchFile(ctx context.Context, file string, patch []byte) error {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	buf, err := ApplyPatch(src, patch)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return Do(ctx, fmt.Sprintf("patch %s", file), func() error {
+		i, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		return WriteFileAtomic(file, buf, i.Mode().Perm())
+	})
+}
+
+// splitLines splits buf after each newline, keeping them.
+func splitLines(buf []byte) []string {
+	var lines []string
+	for len(buf) > 0 {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			i = len(buf) - 1
+		}
+		lines = append(lines, string(buf[:i+1]))
+		buf = buf[i+1:]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script turning a into b, computed with
+// the algorithm of Myers.
+func diffLines(a, b []string) []diffOp {
+	var head, tail []diffOp
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		head = append(head, diffOp{kind: ' ', line: a[0], i: len(head), j: len(head)})
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		tail = append(tail, diffOp{kind: ' ', line: a[len(a)-1]})
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+	var (
+		n, m  = len(a), len(b)
+		off   = n + m + 1
+		v     = make([]int, 2*off+1)
+		trace [][]int
+	)
+search:
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+	var (
+		ops  []diffOp
+		x, y = n, m
+	)
+	for d := len(trace) - 1; d >= 0; d-- {
+		var (
+			v  = trace[d]
+			k  = x - y
+			pk int
+		)
+		if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+			pk = k + 1
+		} else {
+			pk = k - 1
+		}
+		px := v[off+pk]
+		py := px - pk
+		for x > px && y > py {
+			x, y = x-1, y-1
+			ops = append(ops, diffOp{kind: ' ', line: a[x]})
+		}
+		if d == 0 {
+			break
+		}
+		if x == px {
+			ops = append(ops, diffOp{kind: '+', line: b[py]})
+		} else {
+			ops = append(ops, diffOp{kind: '-', line: a[px]})
+		}
+		x, y = px, py
+	}
+	for i := len(ops) - 1; i >= 0; i-- {
+		head = append(head, ops[i])
+	}
+	for i := len(tail) - 1; i >= 0; i-- {
+		head = append(head, tail[i])
+	}
+	var i, j int
+	for k := range head {
+		head[k].i, head[k].j = i, j
+		if head[k].kind != '+' {
+			i++
+		}
+		if head[k].kind != '-' {
+			j++
+		}
+	}
+	return head
+}
+
+// diffHunks groups the changes of ops with the lines around them.
+func diffHunks(ops []diffOp) [][]diffOp {
+	var (
+		hunks [][]diffOp
+		start = -1
+		end   int
+	)
+	for k, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		if start >= 0 && k-diffContext > end {
+			hunks = append(hunks, ops[start:end])
+			start = -1
+		}
+		if start < 0 {
+			start = k - diffContext
+			if start < 0 {
+				start = 0
+			}
+		}
+		end = k + 1 + diffContext
+		if end > len(ops) {
+			end = len(ops)
+		}
+	}
+	if start >= 0 {
+		hunks = append(hunks, ops[start:end])
+	}
+	return hunks
+}
+
+func hunkHeader(h []diffOp) string {
+	var old, new int
+	for _, op := range h {
+		if op.kind != '+' {
+			old++
+		}
+		if op.kind != '-' {
+			new++
+		}
+	}
+	rng := func(start, count int) string {
+		if count > 0 {
+			start++
+		}
+		if count == 1 {
+			return strconv.Itoa(start)
+		}
+		return fmt.Sprintf("%d,%d", start, count)
+	}
+	return fmt.Sprintf("@@ -%s +%s @@", rng(h[0].i, old), rng(h[0].j, new))
+}
+
+type patchHunk struct {
+	start int
+	old   []string
+	new   []string
+}
+
+func (h patchHunk) matchAt(lines []string, at int) bool {
+	if at < 0 || at+len(h.old) > len(lines) {
+		return false
+	}
+	for i, s := range h.old {
+		if lines[at+i] != s {
+			return false
+		}
+	}
+	return true
+}
+
+func parsePatch(patch []byte) ([]patchHunk, error) {
+	var (
+		hunks []patchHunk
+		lines = splitLines(patch)
+	)
+	for k := 0; k < len(lines); k++ {
+		if !strings.HasPrefix(lines[k], "@@ ") {
+			continue
+		}
+		var (
+			h          patchHunk
+			old, new   int
+			oldc, newc int
+			last       byte
+		)
+		fields := strings.Fields(lines[k])
+		if len(fields) < 4 || fields[3] != "@@" {
+			return nil, fmt.Errorf("invalid hunk header %q", strings.TrimSpace(lines[k]))
+		}
+		h.start, oldc = parseRange(strings.TrimPrefix(fields[1], "-"))
+		_, newc = parseRange(strings.TrimPrefix(fields[2], "+"))
+		if h.start < 0 || oldc < 0 || newc < 0 {
+			return nil, fmt.Errorf("invalid hunk header %q", strings.TrimSpace(lines[k]))
+		}
+		if oldc > 0 {
+			h.start--
+		}
+		for k+1 < len(lines) && (old < oldc || new < newc || strings.HasPrefix(lines[k+1], "\\")) {
+			k++
+			line := lines[k]
+			if line == "\n" {
+				line = " \n"
+			}
+			kind, text := line[0], line[1:]
+			switch kind {
+			case ' ':
+				h.old = append(h.old, text)
+				h.new = append(h.new, text)
+				old, new = old+1, new+1
+			case '-':
+				h.old = append(h.old, text)
+				old++
+			case '+':
+				h.new = append(h.new, text)
+				new++
+			case '\\':
+				if last == 0 {
+					return nil, fmt.Errorf("invalid line in hunk: %q", strings.TrimSpace(line))
+				}
+				if last != '+' {
+					h.old[len(h.old)-1] = strings.TrimSuffix(h.old[len(h.old)-1], "\n")
+				}
+				if last != '-' {
+					h.new[len(h.new)-1] = strings.TrimSuffix(h.new[len(h.new)-1], "\n")
+				}
+				continue
+			default:
+				return nil, fmt.Errorf("invalid line in hunk: %q", strings.TrimSpace(line))
+			}
+			last = kind
+		}
+		if old != oldc || new != newc {
+			return nil, fmt.Errorf("truncated hunk at line %d", k+1)
+		}
+		hunks = append(hunks, h)
+	}
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("no hunk in patch")
+	}
+	return hunks, nil
+}
+
+// parseRange parses the range of a hunk header, like 12,3 or 12.
+func parseRange(str string) (int, int) {
+	count := "1"
+	if i := strings.Index(str, ","); i >= 0 {
+		str, count = str[:i], str[i+1:]
+	}
+	start, err := strconv.Atoi(str)
+	if err != nil {
+		return -1, -1
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return -1, -1
+	}
+	return start, n
+}
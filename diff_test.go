@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{a: "a\nb\n", b: "a\nb\n", want: ""},
+		{
+			a:    "a\nb\nc\n",
+			b:    "a\nB\nc\n",
+			want: "--- x\n+++ y\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			a:    "a\n",
+			b:    "a\nb",
+			want: "--- x\n+++ y\n@@ -1 +1,2 @@\n a\n+b\n\\ No newline at end of file\n",
+		},
+		{
+			a:    "",
+			b:    "new\n",
+			want: "--- x\n+++ y\n@@ -0,0 +1 @@\n+new\n",
+		},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := Diff(context.Background(), &buf, "x", "y", []byte(tt.a), []byte(tt.b)); err != nil {
+			t.Errorf("%q -> %q: unexpected error: %s", tt.a, tt.b, err)
+			continue
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%q -> %q: got\n%s\nwant\n%s", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	lines := func(n int, change map[int]string) string {
+		var buf strings.Builder
+		for i := 1; i <= n; i++ {
+			if str, ok := change[i]; ok {
+				buf.WriteString(str)
+				continue
+			}
+			buf.WriteString(strings.Repeat("x", i%7) + "\n")
+		}
+		return buf.String()
+	}
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{name: "change", a: "a\nb\nc\n", b: "a\nB\nc\n"},
+		{name: "append", a: "a\n", b: "a\nb\nc\n"},
+		{name: "remove all", a: "a\nb\n", b: ""},
+		{name: "no newline", a: "a\nb", b: "a\nc"},
+		{name: "hunks", a: lines(40, nil), b: lines(40, map[int]string{2: "two\n", 20: "", 38: "thirty eight\nmore\n"})},
+	}
+	for _, tt := range tests {
+		var patch bytes.Buffer
+		if err := Diff(context.Background(), &patch, "a", "b", []byte(tt.a), []byte(tt.b)); err != nil {
+			t.Errorf("%s: unexpected diff error: %s", tt.name, err)
+			continue
+		}
+		got, err := ApplyPatch([]byte(tt.a), patch.Bytes())
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.name, err)
+			continue
+		}
+		if string(got) != tt.b {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.b)
+		}
+	}
+}
+
+func TestApplyPatchMoved(t *testing.T) {
+	patch := "--- a\n+++ b\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"
+	got, err := ApplyPatch([]byte("header\na\nb\nc\n"), []byte(patch))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want := "header\na\nB\nc\n"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := ApplyPatch([]byte("a\nx\nc\n"), []byte(patch)); err == nil {
+		t.Errorf("expected error applying a hunk not found")
+	}
+}
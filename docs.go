@@ -0,0 +1,279 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DocsFormat selects the static site generator the pages written by WriteDocs
+// are meant for.
+type DocsFormat string
+
+const (
+	// DocsMarkdown writes plain markdown pages.
+	DocsMarkdown DocsFormat = "markdown"
+	// DocsHugo adds the front matter of Hugo and an _index.md page.
+	DocsHugo DocsFormat = "hugo"
+	// DocsDocusaurus adds the front matter of Docusaurus and a
+	// _category_.json file for its sidebar.
+	DocsDocusaurus DocsFormat = "docusaurus"
+	// DocsMan writes man pages, in section 1, named like git-commit.1.
+	DocsMan DocsFormat = "man"
+)
+
+// DocsFormats are the formats supported by WriteDocs.
+var DocsFormats = []string{string(DocsMarkdown), string(DocsHugo), string(DocsDocusaurus), string(DocsMan)}
+
+// DocsPage is an entry of the manifest.json file written by WriteDocs.
+type DocsPage struct {
+	Command string `json:"command"`
+	Title   string `json:"title"`
+	File    string `json:"file"`
+	Short   string `json:"short,omitempty"`
+	Weight  int    `json:"weight"`
+}
+
+// WriteDocs writes in dir a markdown page for each command of spec, as given
+// by Describe, and a manifest.json file listing them in order, for the tools
+// building the menus of a documentation site. The headings of the pages are
+// translated in the language of spec, set by Spec.Translate. With DocsMan, the
+// pages are man pages and there is no manifest.
+func WriteDocs(dir string, spec Spec, format DocsFormat) error {
+	return writeDocs(dir, spec, format, NativeEOL)
+}
+
+func writeDocs(dir string, spec Spec, format DocsFormat, eol LineEnding) error {
+	if format == "" {
+		format = DocsMarkdown
+	}
+	if !containsString(DocsFormats, string(format)) {
+		return choiceError("format", string(format), DocsFormats)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	var pages []DocsPage
+	for i, c := range spec.Commands {
+		p := DocsPage{
+			Command: c.Name,
+			Title:   spec.Name + " " + c.Name,
+			File:    c.Name + ".md",
+			Short:   c.Short,
+			Weight:  i + 1,
+		}
+		var buf bytes.Buffer
+		if format == DocsMan {
+			p.File = spec.Name + "-" + c.Name + ".1"
+			writeManPage(&buf, spec, p, c)
+		} else {
+			writeFrontMatter(&buf, format, p)
+			writeCommandPage(&buf, spec.Lang, p, c)
+		}
+		if err := WriteFile(filepath.Join(dir, p.File), buf.Bytes(), 0644, eol); err != nil {
+			return err
+		}
+		pages = append(pages, p)
+	}
+	switch format {
+	case DocsHugo:
+		var buf bytes.Buffer
+		writeFrontMatter(&buf, format, DocsPage{Title: spec.Name, Short: TranslateTo(spec.Lang, "command line reference")})
+		fmt.Fprintf(&buf, "# %s\n\n", spec.Name)
+		for _, p := range pages {
+			fmt.Fprintf(&buf, "- [%s](%s)", p.Title, strings.TrimSuffix(p.File, ".md"))
+			if p.Short != "" {
+				fmt.Fprintf(&buf, ": %s", p.Short)
+			}
+			buf.WriteString("\n")
+		}
+		if err := WriteFile(filepath.Join(dir, "_index.md"), buf.Bytes(), 0644, eol); err != nil {
+			return err
+		}
+	case DocsDocusaurus:
+		buf, _ := json.MarshalIndent(map[string]interface{}{
+			"label":    spec.Name,
+			"position": 1,
+		}, "", "  ")
+		if err := WriteFile(filepath.Join(dir, "_category_.json"), append(buf, '\n'), 0644, eol); err != nil {
+			return err
+		}
+	case DocsMan:
+		return nil
+	}
+	buf, err := json.MarshalIndent(pages, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(filepath.Join(dir, "manifest.json"), append(buf, '\n'), 0644, eol)
+}
+
+func writeFrontMatter(buf *bytes.Buffer, format DocsFormat, p DocsPage) {
+	if format == DocsMarkdown {
+		return
+	}
+	buf.WriteString("---\n")
+	fmt.Fprintf(buf, "title: %s\n", strconv.Quote(p.Title))
+	if p.Short != "" {
+		fmt.Fprintf(buf, "description: %s\n", strconv.Quote(p.Short))
+	}
+	if p.Weight > 0 {
+		switch format {
+		case DocsHugo:
+			fmt.Fprintf(buf, "weight: %d\n", p.Weight)
+		case DocsDocusaurus:
+			fmt.Fprintf(buf, "sidebar_position: %d\n", p.Weight)
+			fmt.Fprintf(buf, "sidebar_label: %s\n", strconv.Quote(p.Command))
+		}
+	}
+	buf.WriteString("---\n\n")
+}
+
+func writeCommandPage(buf *bytes.Buffer, lang string, p DocsPage, c CommandSpec) {
+	tr := func(msg string) string {
+		return TranslateTo(lang, msg)
+	}
+	fmt.Fprintf(buf, "# %s\n\n", p.Title)
+	if c.Short != "" {
+		fmt.Fprintf(buf, "%s\n\n", c.Short)
+	}
+	fmt.Fprintf(buf, "## %s\n\n```\n%s\n```\n\n", tr("Usage"), c.Usage)
+	if len(c.Alias) > 0 {
+		fmt.Fprintf(buf, "%s: %s\n\n", tr("Aliases"), strings.Join(c.Alias, ", "))
+	}
+	if c.Desc != "" {
+		fmt.Fprintf(buf, "%s\n\n", c.Desc)
+	}
+	if len(c.Flags) > 0 {
+		fmt.Fprintf(buf, "## %s\n\n| %s | %s | %s | %s |\n|---|---|---|---|\n", tr("Flags"), tr("Flag"), tr("Type"), tr("Default"), tr("Description"))
+		for _, f := range c.Flags {
+			fmt.Fprintf(buf, "| `-%s` | %s | %s | %s |\n", f.Name, f.Type, markdownCell(f.Default), markdownCell(f.Usage))
+		}
+		buf.WriteString("\n")
+	}
+	if len(c.Examples) > 0 {
+		fmt.Fprintf(buf, "## %s\n\n", tr("Examples"))
+		for _, e := range c.Examples {
+			if e.Desc != "" {
+				fmt.Fprintf(buf, "%s\n\n", e.Desc)
+			}
+			fmt.Fprintf(buf, "```\n$ %s\n", e.Cmd)
+			if e.Output != "" {
+				fmt.Fprintf(buf, "%s\n", strings.TrimRight(e.Output, "\n"))
+			}
+			buf.WriteString("```\n\n")
+		}
+	}
+}
+
+// writeManPage writes the page of c in the roff format of the man pages, its
+// sections named in the language of spec.
+func writeManPage(buf *bytes.Buffer, spec Spec, p DocsPage, c CommandSpec) {
+	tr := func(msg string) string {
+		return strings.ToUpper(TranslateTo(spec.Lang, msg))
+	}
+	fmt.Fprintf(buf, ".TH %s 1 \"\" %s\n", roffQuote(strings.ToUpper(spec.Name+"-"+c.Name)), roffQuote(strings.TrimSpace(spec.Name+" "+spec.Version)))
+	fmt.Fprintf(buf, ".SH %s\n%s", tr("Name"), roffText(p.Title))
+	if c.Short != "" {
+		fmt.Fprintf(buf, " \\- %s", roffText(c.Short))
+	}
+	fmt.Fprintf(buf, "\n.SH %s\n.nf\n%s\n.fi\n", tr("Usage"), roffText(c.Usage))
+	if len(c.Alias) > 0 {
+		fmt.Fprintf(buf, ".SH %s\n%s\n", tr("Aliases"), roffText(strings.Join(c.Alias, ", ")))
+	}
+	if c.Desc != "" {
+		fmt.Fprintf(buf, ".SH %s\n%s\n", tr("Description"), roffText(c.Desc))
+	}
+	if len(c.Flags) > 0 {
+		fmt.Fprintf(buf, ".SH %s\n", tr("Flags"))
+		for _, f := range c.Flags {
+			fmt.Fprintf(buf, ".TP\n\\fB\\-%s\\fR", roffText(f.Name))
+			if f.Type != "" && f.Type != "bool" {
+				fmt.Fprintf(buf, " \\fI%s\\fR", roffText(f.Type))
+			}
+			fmt.Fprintf(buf, "\n%s", roffText(f.Usage))
+			if f.Default != "" {
+				fmt.Fprintf(buf, " (%s: %s)", TranslateTo(spec.Lang, "default"), roffText(f.Default))
+			}
+			buf.WriteString("\n")
+		}
+	}
+	if len(c.Examples) > 0 {
+		fmt.Fprintf(buf, ".SH %s\n", tr("Examples"))
+		for _, e := range c.Examples {
+			if e.Desc != "" {
+				fmt.Fprintf(buf, ".PP\n%s\n", roffText(e.Desc))
+			}
+			fmt.Fprintf(buf, ".PP\n.nf\n$ %s\n", roffText(e.Cmd))
+			if e.Output != "" {
+				fmt.Fprintf(buf, "%s\n", roffText(strings.TrimRight(e.Output, "\n")))
+			}
+			buf.WriteString(".fi\n")
+		}
+	}
+}
+
+// roffText escapes str for the text of a man page, so that its backslashes
+// and its lines starting with a dot or a quote are not taken for requests.
+func roffText(str string) string {
+	str = strings.ReplaceAll(str, `\`, `\e`)
+	lines := strings.Split(str, "\n")
+	for i, s := range lines {
+		if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+			lines[i] = `\&` + s
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func roffQuote(str string) string {
+	return `"` + strings.ReplaceAll(roffText(str), `"`, `""`) + `"`
+}
+
+func markdownCell(str string) string {
+	if str == "" {
+		return ""
+	}
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(str)
+}
+
+// DocsCommand returns a builtin command writing the reference of the commands
+// of the app in a directory, for a documentation site:
+//
+//	docs [-format markdown|hugo|docusaurus|man] [-lang lang] <dir>
+//
+// With -lang, the pages are translated with the catalogs registered for the
+// language, so that a set of pages can be written for each of them.
+func DocsCommand() *Command {
+	var (
+		format = Enum{Values: DocsFormats, Value: string(DocsMarkdown)}
+		lang   string
+		cmd    = Command{
+			Usage: "docs [-format format] [-lang lang] <dir>",
+			Short: "write the reference documentation of the commands",
+		}
+	)
+	cmd.Flag.Var(&format, "format", "format of the pages")
+	cmd.Flag.StringVar(&lang, "lang", "", "language of the pages")
+	cmd.Run = func(c *Command, args []string) error {
+		if err := c.Parse(args); err != nil {
+			return err
+		}
+		a := appFrom(c.Context())
+		if a == nil {
+			return fmt.Errorf("docs: command outside of an app")
+		}
+		dir := c.Flag.Arg(0)
+		if dir == "" {
+			return fmt.Errorf("docs: missing directory")
+		}
+		return Do(c.Context(), "write documentation in "+dir, func() error {
+			return writeDocs(dir, a.docsSpec(lang), DocsFormat(format.Value), EOL(c.Context()))
+		})
+	}
+	return &cmd
+}
@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+type dryRunKey struct{}
+
+// DryRun reports whether the global --dry-run flag was given.
+func DryRun(ctx context.Context) bool {
+	_, ok := ctx.Value(dryRunKey{}).(io.Writer)
+	return ok
+}
+
+// Do runs fn unless in dry-run mode. In that case, desc is printed on the
+// standard error of the app instead.
+func Do(ctx context.Context, desc string, fn func() error) error {
+	if w, ok := ctx.Value(dryRunKey{}).(io.Writer); ok {
+		fmt.Fprintf(w, "dry-run: %s\n", desc)
+		return nil
+	}
+	return fn()
+}
+
+func withDryRun(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, w)
+}
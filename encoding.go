@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	cp437  = "ÇüéâäàåçêëèïîìÄÅÉæÆôöòûùÿÖÜ¢£¥₧ƒáíóúñÑªº¿⌐¬½¼¡«»░▒▓│┤╡╢╖╕╣║╗╝╜╛┐└┴┬├─┼╞╟╚╔╩╦╠═╬╧╨╤╥╙╘╒╓╫╪┘┌█▄▌▐▀αßΓπΣσµτΦΘΩδ∞φε∩≡±≥≤⌠⌡÷≈°∙·√ⁿ²■\u00a0"
+	cp850  = "ÇüéâäàåçêëèïîìÄÅÉæÆôöòûùÿÖÜø£Ø×ƒáíóúñÑªº¿®¬½¼¡«»░▒▓│┤ÁÂÀ©╣║╗╝¢¥┐└┴┬├─┼ãÃ╚╔╩╦╠═╬¤ðÐÊËÈıÍÎÏ┘┌█▄¦Ì▀ÓßÔÒõÕµþÞÚÛÙýÝ¯´\u00ad±‗¾¶§÷¸°¨·¹³²■\u00a0"
+	cp1252 = "€\ufffd‚ƒ„…†‡ˆ‰Š‹Œ\ufffdŽ\ufffd\ufffd‘’“”•–—˜™š›œ\ufffdžŸ\u00a0¡¢£¤¥¦§¨©ª«¬\u00ad®¯°±²³´µ¶·¸¹º»¼½¾¿ÀÁÂÃÄÅÆÇÈÉÊËÌÍÎÏÐÑÒÓÔÕÖ×ØÙÚÛÜÝÞßàáâãäåæçèéêëìíîïðñòóôõö÷øùúûüýþÿ"
+)
+
+type charset func([]byte, rune) []byte
+
+// Encode returns a writer that transcodes the UTF-8 text written to it into the
+// given encoding before writing it to w. Characters that can not be represented
+// in the target encoding are replaced by a question mark. Unless the encoding is
+// UTF-8, the writer returned is an io.Closer, whose Close method writes the
+// incomplete character ending the text, if any, as U+FFFD, without closing w.
+func Encode(w io.Writer, name string) (io.Writer, error) {
+	cs, bom, err := lookupEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	if cs == nil {
+		return w, nil
+	}
+	return &encoder{
+		inner:   w,
+		charset: cs,
+		bom:     bom,
+	}, nil
+}
+
+var encodings = []string{"utf-8", "utf-16", "utf-16le", "utf-16be", "latin1", "cp1252", "cp437", "cp850"}
+
+func lookupEncoding(name string) (charset, []byte, error) {
+	switch strings.ToLower(name) {
+	case "", "utf-8", "utf8":
+		return nil, nil, nil
+	case "utf-16", "utf16":
+		return utf16LE, []byte{0xff, 0xfe}, nil
+	case "utf-16le", "utf16le":
+		return utf16LE, nil, nil
+	case "utf-16be", "utf16be":
+		return utf16BE, nil, nil
+	case "iso-8859-1", "latin1":
+		return latin1, nil, nil
+	case "windows-1252", "cp1252":
+		return codepage(cp1252), nil, nil
+	case "cp437", "ibm437":
+		return codepage(cp437), nil, nil
+	case "cp850", "ibm850":
+		return codepage(cp850), nil, nil
+	default:
+		return nil, nil, choiceError("encoding", name, encodings)
+	}
+}
+
+type encoder struct {
+	inner   io.Writer
+	charset charset
+	bom     []byte
+	rest    []byte
+}
+
+func (e *encoder) Write(b []byte) (int, error) {
+	var (
+		buf = append(e.rest, b...)
+		out = e.bom
+	)
+	e.bom = nil
+	for len(buf) > 0 && utf8.FullRune(buf) {
+		r, z := utf8.DecodeRune(buf)
+		out = e.charset(out, r)
+		buf = buf[z:]
+	}
+	e.rest = append([]byte(nil), buf...)
+	if _, err := e.inner.Write(out); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (e *encoder) Close() error {
+	if len(e.rest) == 0 {
+		return nil
+	}
+	out := e.charset(e.bom, utf8.RuneError)
+	e.bom, e.rest = nil, nil
+	_, err := e.inner.Write(out)
+	return err
+}
+
+func utf16LE(b []byte, r rune) []byte {
+	for _, c := range utf16.Encode([]rune{r}) {
+		b = append(b, byte(c), byte(c>>8))
+	}
+	return b
+}
+
+func utf16BE(b []byte, r rune) []byte {
+	for _, c := range utf16.Encode([]rune{r}) {
+		b = append(b, byte(c>>8), byte(c))
+	}
+	return b
+}
+
+func latin1(b []byte, r rune) []byte {
+	if r > 0xff {
+		r = '?'
+	}
+	return append(b, byte(r))
+}
+
+func codepage(table string) charset {
+	set := make(map[rune]byte)
+	for i, r := range []rune(table) {
+		if r == utf8.RuneError {
+			continue
+		}
+		set[r] = byte(0x80 + i)
+	}
+	return func(b []byte, r rune) []byte {
+		if r < utf8.RuneSelf {
+			return append(b, byte(r))
+		}
+		c, ok := set[r]
+		if !ok {
+			c = '?'
+		}
+		return append(b, c)
+	}
+}
+
+type encodingValue struct {
+	name *string
+}
+
+func (e encodingValue) Set(str string) error {
+	if _, _, err := lookupEncoding(str); err != nil {
+		return err
+	}
+	*e.name = str
+	return nil
+}
+
+func (e encodingValue) String() string {
+	if e.name == nil {
+		return ""
+	}
+	return *e.name
+}
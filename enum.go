@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Enum is a flag value only accepting one of Values. An invalid value is
+// reported with the valid ones and, when it looks like a typo, the nearest.
+type Enum struct {
+	Values []string
+	Value  string
+}
+
+func (e *Enum) Set(str string) error {
+	for _, v := range e.Values {
+		if v == str {
+			e.Value = str
+			return nil
+		}
+	}
+	return choiceError("value", str, e.Values)
+}
+
+func (e *Enum) reset(def string) {
+	e.Value = def
+}
+
+func (e *Enum) String() string {
+	if e == nil {
+		return ""
+	}
+	return e.Value
+}
+
+func (e *Enum) Type() string {
+	return strings.Join(e.Values, "|")
+}
+
+func choiceError(what, str string, values []string) error {
+	if s := suggestChoice(str, values); s != "" {
+		return fmt.Errorf("%s: invalid %s, did you mean %q? (expected %s)", str, what, s, joinChoices(values))
+	}
+	return fmt.Errorf("%s: invalid %s (expected %s)", str, what, joinChoices(values))
+}
+
+// suggestChoice returns the value the nearest to str, if it is close enough
+// to be a typo: a third of the length of str or two edits at most. The first
+// of the values equally close is returned.
+func suggestChoice(str string, values []string) string {
+	if str == "" {
+		return ""
+	}
+	str = strings.ToLower(str)
+	limit := utf8.RuneCountInString(str) / 3
+	if limit < 2 {
+		limit = 2
+	}
+	var best string
+	for _, v := range values {
+		if d := editDistance(str, strings.ToLower(v)); d <= limit {
+			best, limit = v, d-1
+		}
+	}
+	return best
+}
+
+func joinChoices(values []string) string {
+	switch n := len(values); n {
+	case 0:
+		return ""
+	case 1:
+		return values[0]
+	default:
+		return strings.Join(values[:n-1], ", ") + " or " + values[n-1]
+	}
+}
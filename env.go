@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// SensitiveEnv are patterns matching the names of variables that usually
+// hold secrets. They are meant to be used as Env.Deny.
+var SensitiveEnv = []string{
+	"*TOKEN*",
+	"*SECRET*",
+	"*PASSWORD*",
+	"*PASSWD*",
+	"*CREDENTIAL*",
+	"*_KEY",
+	"*_KEY_ID",
+}
+
+// Env describes the environment of a child process. Allow and Deny contain
+// patterns in the syntax of path.Match applied to the names of the variables
+// of the current process.
+type Env struct {
+	// Inherit starts from the environment of the current process. Otherwise,
+	// the child only gets the variables of Set.
+	Inherit bool
+	// Allow, when not empty, restricts the inherited variables to the ones
+	// matching one of its patterns.
+	Allow []string
+	// Deny removes the inherited variables matching one of its patterns.
+	Deny []string
+	// Set adds variables, overriding the inherited ones.
+	Set map[string]string
+}
+
+// Environ returns the environment described by e, sorted by name.
+func (e Env) Environ() []string {
+	set := make(map[string]string)
+	if e.Inherit {
+		for _, kv := range os.Environ() {
+			i := strings.Index(kv, "=")
+			if i <= 0 {
+				continue
+			}
+			k := kv[:i]
+			if len(e.Allow) > 0 && !matchEnv(e.Allow, k) {
+				continue
+			}
+			if matchEnv(e.Deny, k) {
+				continue
+			}
+			set[k] = kv[i+1:]
+		}
+	}
+	for k, v := range e.Set {
+		set[k] = v
+	}
+	list := make([]string, 0, len(set))
+	for k, v := range set {
+		list = append(list, k+"="+v)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Command is like exec.CommandContext but runs the command in the environment
+// described by e.
+func (e Env) Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = e.Environ()
+	return cmd
+}
+
+func matchEnv(patterns []string, name string) bool {
+	if runtime.GOOS == "windows" {
+		name = strings.ToUpper(name)
+	}
+	for _, p := range patterns {
+		if runtime.GOOS == "windows" {
+			p = strings.ToUpper(p)
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveEnv records the environment of the current process. Calling the
+// returned function restores it.
+func SaveEnv() func() {
+	saved := os.Environ()
+	return func() {
+		os.Clearenv()
+		for _, kv := range saved {
+			if i := strings.Index(kv, "="); i > 0 {
+				os.Setenv(kv[:i], kv[i+1:])
+			}
+		}
+	}
+}
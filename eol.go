@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+)
+
+// LineEnding selects the line endings of the text files written by the app.
+// It is set for a run with the global --eol flag and given by EOL.
+type LineEnding int
+
+const (
+	NativeEOL LineEnding = iota
+	LF
+	CRLF
+)
+
+func (e LineEnding) String() string {
+	switch e {
+	case LF:
+		return "lf"
+	case CRLF:
+		return "crlf"
+	default:
+		return "native"
+	}
+}
+
+func (e *LineEnding) Set(str string) error {
+	switch strings.ToLower(str) {
+	case "native", "":
+		*e = NativeEOL
+	case "lf", "unix":
+		*e = LF
+	case "crlf", "windows", "dos":
+		*e = CRLF
+	default:
+		return choiceError("line ending", str, []string{"native", "lf", "crlf"})
+	}
+	return nil
+}
+
+// Convert normalizes all the line endings found in b according to e.
+func (e LineEnding) Convert(b []byte) []byte {
+	b = bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
+	if e.crlf() {
+		b = bytes.ReplaceAll(b, []byte("\n"), []byte("\r\n"))
+	}
+	return b
+}
+
+func (e LineEnding) crlf() bool {
+	if e == NativeEOL {
+		return runtime.GOOS == "windows"
+	}
+	return e == CRLF
+}
+
+// WriteFile writes data to the named file, like WriteFileAtomic, after having
+// converted its line endings according to eol.
+func WriteFile(file string, data []byte, perm os.FileMode, eol LineEnding) error {
+	return WriteFileAtomic(file, eol.Convert(data), perm)
+}
+
+type eolKey struct{}
+
+// EOL returns the line endings of the text files written by the command, as
+// given by the global --eol flag.
+func EOL(ctx context.Context) LineEnding {
+	e, _ := ctx.Value(eolKey{}).(LineEnding)
+	return e
+}
+
+func withEOL(ctx context.Context, e LineEnding) context.Context {
+	return context.WithValue(ctx, eolKey{}, e)
+}
+
+// isText reports whether buf looks like text, whose line endings can be
+// converted without damage.
+func isText(buf []byte) bool {
+	return bytes.IndexByte(buf, 0) < 0 && utf8.Valid(buf)
+}
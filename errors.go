@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Errors collects the errors of several independent operations, typically
+// the items processed by a batch command, so that all the failures are
+// reported instead of only the first one.
+type Errors []error
+
+// Add appends err when it is not nil.
+func (e *Errors) Add(err error) {
+	if err != nil {
+		*e = append(*e, err)
+	}
+}
+
+func (e Errors) Error() string {
+	list := make([]string, len(e))
+	for i := range e {
+		list[i] = e[i].Error()
+	}
+	return strings.Join(list, "\n")
+}
+
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// Err returns nil when no error has been collected.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e Errors) print(w io.Writer) {
+	fmt.Fprintf(w, "%d errors:\n", len(e))
+	for _, err := range e {
+		msg := strings.ReplaceAll(err.Error(), "\n", "\n    ")
+		fmt.Fprintf(w, "  - %s\n", msg)
+	}
+}
+
+// ExitPolicy selects the exit code of a program whose command failed with
+// Errors.
+type ExitPolicy int
+
+const (
+	// ExitFirst uses the exit code of the first error.
+	ExitFirst ExitPolicy = iota
+	// ExitMax uses the highest exit code of the errors.
+	ExitMax
+	// ExitPartial uses PartialExitCode, telling that some of the operations
+	// failed.
+	ExitPartial
+)
+
+const PartialExitCode = 3
+
+func (p ExitPolicy) code(errs Errors) int {
+	switch {
+	case len(errs) == 0:
+		return 0
+	case p == ExitPartial:
+		return PartialExitCode
+	case p == ExitMax:
+		var code int
+		for _, err := range errs {
+			if c := ExitCode(err); c > code {
+				code = c
+			}
+		}
+		return code
+	default:
+		return ExitCode(errs[0])
+	}
+}
+
+func exitCode(err error, policy ExitPolicy) int {
+	var errs Errors
+	if errors.As(err, &errs) {
+		return policy.code(errs)
+	}
+	return ExitCode(err)
+}
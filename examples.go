@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Examples returns a builtin command giving access to the example files
+// embedded in the binary, typically with a go:embed directive:
+//
+//	//go:embed examples
+//	var samples embed.FS
+//
+//	cmd := cli.Examples(samples, "examples")
+//
+// The command supports three actions: list, show <name> and export <name>.
+// Export copies the named file or directory into the current directory (or
+// the one given with -d) and refuses to overwrite existing files unless -f
+// is given.
+func Examples(fsys fs.FS, root string) *Command {
+	var (
+		dir   string
+		force bool
+		cmd   = Command{
+			Usage: "examples [-d dir] [-f] <list|show|export> [<name>]",
+			Short: "list and export the embedded examples",
+		}
+	)
+	if root != "" && root != "." {
+		sub, err := fs.Sub(fsys, root)
+		if err == nil {
+			fsys = sub
+		}
+	}
+	cmd.Flag.StringVar(&dir, "d", ".", "export examples into directory")
+	cmd.Flag.BoolVar(&force, "f", false, "overwrite existing files")
+	cmd.Run = func(c *Command, args []string) error {
+		if err := c.Parse(args); err != nil {
+			return err
+		}
+		var (
+			action = c.Flag.Arg(0)
+			name   = c.Flag.Arg(1)
+		)
+		if action != "list" && action != "" && !fs.ValidPath(name) {
+			return fmt.Errorf("%s: invalid example name", name)
+		}
+		switch action {
+		case "list", "":
+			return listExamples(c.Stdout(), fsys)
+		case "show":
+			return showExample(c.Stdout(), fsys, name)
+		case "export":
+			return exportExample(c.Context(), fsys, name, dir, force)
+		default:
+			return fmt.Errorf("%s: unknown action", action)
+		}
+	}
+	return &cmd
+}
+
+func listExamples(w io.Writer, fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(file string, e fs.DirEntry, err error) error {
+		if err != nil || file == "." {
+			return err
+		}
+		if e.IsDir() {
+			file += "/"
+		}
+		fmt.Fprintln(w, file)
+		return nil
+	})
+}
+
+func showExample(w io.Writer, fsys fs.FS, name string) error {
+	r, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func exportExample(ctx context.Context, fsys fs.FS, name, dir string, force bool) error {
+	if _, err := fs.Stat(fsys, name); err != nil {
+		return err
+	}
+	base := path.Dir(name)
+	return fs.WalkDir(fsys, name, func(file string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := file
+		if base != "." {
+			rel = file[len(base)+1:]
+		}
+		target := filepath.Join(dir, filepath.FromSlash(rel))
+		if e.IsDir() {
+			return Do(ctx, "create directory "+target, func() error {
+				return os.MkdirAll(target, 0755)
+			})
+		}
+		if _, err := os.Stat(target); err == nil && !force {
+			return fmt.Errorf("%s: file already exists", target)
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		buf, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		return Do(ctx, "write "+target, func() error {
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if !isText(buf) {
+				return WriteFileAtomic(target, buf, 0644)
+			}
+			return WriteFile(target, buf, 0644, EOL(ctx))
+		})
+	})
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Exec runs cmd, an external program run on behalf of c, with the standard
+// streams of c when cmd does not set them. When cmd fails, the returned error
+// has the exit code of cmd, or 128 plus the number of the signal that killed
+// it, so that the program exits like cmd did. The status of cmd is available
+// with errors.As and an *exec.ExitError.
+func Exec(c *Command, cmd *exec.Cmd) error {
+	if cmd.Stdin == nil {
+		cmd.Stdin = c.Stdin()
+	}
+	if cmd.Stdout == nil {
+		cmd.Stdout = c.Stdout()
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = c.Stderr()
+	}
+	err := cmd.Run()
+	if err == nil {
+		return nil
+	}
+	var exit *exec.ExitError
+	if !errors.As(err, &exit) {
+		return err
+	}
+	err = fmt.Errorf("%s: %w", filepath.Base(cmd.Path), err)
+	return Exit(err, processExitCode(exit.ProcessState))
+}
+
+// processExitCode gives the exit code of a program that ended with ps, 128
+// plus the number of the signal when it was killed.
+func processExitCode(ps *os.ProcessState) int {
+	if code := ps.ExitCode(); code >= 0 {
+		return code
+	}
+	if sig, ok := exitSignal(ps); ok {
+		return 128 + sig
+	}
+	return BadExitCode
+}
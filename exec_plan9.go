@@ -0,0 +1,10 @@
+//go:build plan9
+// +build plan9
+
+package cli
+
+import "os"
+
+func exitSignal(ps *os.ProcessState) (int, bool) {
+	return 0, false
+}
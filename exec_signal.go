@@ -0,0 +1,19 @@
+//go:build !plan9
+// +build !plan9
+
+package cli
+
+import (
+	"os"
+	"syscall"
+)
+
+// exitSignal gives the number of the signal that killed the process that
+// ended with ps, if any.
+func exitSignal(ps *os.ProcessState) (int, bool) {
+	ws, ok := ps.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return 0, false
+	}
+	return int(ws.Signal()), true
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+type exitHook struct {
+	fn func()
+}
+
+var hooks struct {
+	sync.Mutex
+	list []*exitHook
+	sig  chan os.Signal
+	// graceful counts the Shutdown watching signals. While there is one, the
+	// first signal is left to it.
+	graceful int
+}
+
+// atExit registers fn to be run when the process exits because of an error,
+// a panic or an interrupt signal. The returned function unregisters fn.
+func atExit(fn func()) func() {
+	h := &exitHook{fn: fn}
+
+	hooks.Lock()
+	defer hooks.Unlock()
+	hooks.list = append(hooks.list, h)
+	if hooks.sig == nil {
+		hooks.sig = make(chan os.Signal, 1)
+		// on Windows, CTRL_C and CTRL_BREAK are received as os.Interrupt and
+		// the closing of the console as SIGTERM
+		signal.Notify(hooks.sig, os.Interrupt, syscall.SIGTERM)
+		go waitSignal(hooks.sig)
+	}
+	return func() {
+		hooks.Lock()
+		defer hooks.Unlock()
+		for i := range hooks.list {
+			if hooks.list[i] == h {
+				hooks.list = append(hooks.list[:i], hooks.list[i+1:]...)
+				break
+			}
+		}
+		if len(hooks.list) == 0 && hooks.sig != nil {
+			signal.Stop(hooks.sig)
+			close(hooks.sig)
+			hooks.sig = nil
+		}
+	}
+}
+
+// runAtExit runs the registered hooks, the most recent first.
+func runAtExit() {
+	hooks.Lock()
+	list := hooks.list
+	hooks.list = nil
+	hooks.Unlock()
+
+	for i := len(list) - 1; i >= 0; i-- {
+		list[i].fn()
+	}
+}
+
+func exit(code int) {
+	runAtExit()
+	os.Exit(code)
+}
+
+func waitSignal(sig <-chan os.Signal) {
+	for s := range sig {
+		hooks.Lock()
+		graceful := hooks.graceful > 0
+		hooks.Unlock()
+		if !graceful {
+			exit(signalCode(s))
+		}
+	}
+}
+
+func signalCode(s os.Signal) int {
+	if s == syscall.SIGTERM {
+		return 143
+	}
+	return 130
+}
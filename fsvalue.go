@@ -0,0 +1,336 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExpandHome replaces a leading ~ in path by the home directory of the user.
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// File is a flag value for the path of a regular file. Unless Create is set,
+// the file should exist. When Open is set, the file is opened once validated,
+// for reading or, with Create, for writing after being created or truncated.
+// The path - designates the standard input.
+//
+// When URL is set, the value can also be a http or https URL, downloaded with
+// Client, if set, or with a HTTPClient using DefaultCache, so that the offline
+// mode and the cache apply. The download starts at the first call to the Read
+// method of f, with the context of the command that parsed the flag, and the
+// body of the response is read with it; File is nil in that case.
+type File struct {
+	Path   string
+	Create bool
+	Open   bool
+	URL    bool
+	Client *HTTPClient
+
+	*os.File
+	body io.ReadCloser
+	ctx  context.Context
+}
+
+func (f *File) Set(str string) error {
+	if f.body != nil {
+		f.body.Close()
+		f.body = nil
+	}
+	if f.File != nil && f.File != os.Stdin {
+		f.File.Close()
+	}
+	f.File = nil
+	if f.URL && isURL(str) {
+		u, err := url.Parse(str)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("%s: invalid URL", str)
+		}
+		f.Path = u.String()
+		return nil
+	}
+	if str == "-" {
+		f.Path = str
+		if f.Open {
+			f.File = os.Stdin
+		}
+		return nil
+	}
+	path := ExpandHome(str)
+	i, err := os.Stat(path)
+	switch {
+	case err == nil && i.IsDir():
+		return fmt.Errorf("%s: is a directory", str)
+	case errors.Is(err, fs.ErrNotExist) && f.Create:
+		dir, err := os.Stat(filepath.Dir(path))
+		if err != nil || !dir.IsDir() {
+			return fmt.Errorf("%s: parent directory does not exist", str)
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("%s: no such file", str)
+	case err != nil:
+		return fmt.Errorf("%s: %w", str, err)
+	}
+	f.Path = path
+	if !f.Open {
+		return nil
+	}
+	if f.Create {
+		f.File, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	} else {
+		f.File, err = os.Open(path)
+	}
+	return err
+}
+
+func (f *File) reset(def string) {
+	if f.body != nil {
+		f.body.Close()
+	}
+	if f.File != nil && f.File != os.Stdin {
+		f.File.Close()
+	}
+	f.Path, f.File, f.body = def, nil, nil
+}
+
+func (f *File) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.Path
+}
+
+// IsURL reports whether the value of f is a URL.
+func (f *File) IsURL() bool {
+	return f.URL && isURL(f.Path)
+}
+
+func (f *File) Read(b []byte) (int, error) {
+	if f.body == nil && f.Open && f.IsURL() {
+		if err := f.fetch(); err != nil {
+			return 0, err
+		}
+	}
+	if f.body != nil {
+		return f.body.Read(b)
+	}
+	if f.File == nil {
+		return 0, os.ErrInvalid
+	}
+	return f.File.Read(b)
+}
+
+// WriteTo writes the content of f to w. It replaces the method of os.File so
+// that io.Copy reads the URLs too.
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	return io.Copy(w, struct{ io.Reader }{f})
+}
+
+func (f *File) Close() error {
+	if f.body != nil {
+		return f.body.Close()
+	}
+	if f.File == nil {
+		return os.ErrInvalid
+	}
+	return f.File.Close()
+}
+
+func (f *File) fetch() error {
+	ctx := f.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	client := f.Client
+	if client == nil {
+		client = &HTTPClient{Cache: DefaultCache()}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.Path, nil)
+	if err != nil {
+		return err
+	}
+	res, err := client.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		res.Body.Close()
+		return fmt.Errorf("%s: %s", f.Path, res.Status)
+	}
+	f.File, f.body = nil, res.Body
+	return nil
+}
+
+func isURL(str string) bool {
+	return strings.HasPrefix(str, "http://") || strings.HasPrefix(str, "https://")
+}
+
+// Dir is a flag value for the path of a directory. Unless Create is set, the
+// directory should exist. Otherwise, it is created with its parents.
+type Dir struct {
+	Path   string
+	Create bool
+}
+
+func (d *Dir) Set(str string) error {
+	path := ExpandHome(str)
+	i, err := os.Stat(path)
+	switch {
+	case err == nil && !i.IsDir():
+		return fmt.Errorf("%s: not a directory", str)
+	case errors.Is(err, fs.ErrNotExist) && d.Create:
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return fmt.Errorf("%s: %w", str, err)
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("%s: no such directory", str)
+	case err != nil:
+		return fmt.Errorf("%s: %w", str, err)
+	}
+	d.Path = path
+	return nil
+}
+
+func (d *Dir) reset(def string) {
+	d.Path = def
+}
+
+func (d *Dir) String() string {
+	if d == nil {
+		return ""
+	}
+	return d.Path
+}
+
+// Path is a flag value for a path, normalized according to its options. The
+// path is cleaned and a leading ~ is replaced by the home directory.
+type Path struct {
+	Path string
+	// Abs makes the path absolute.
+	Abs bool
+	// Resolve replaces the symbolic links of the path by their targets. The
+	// path is made absolute.
+	Resolve bool
+	// Exist requires the path to exist.
+	Exist bool
+	// Root, when set, requires the path to be Root or under it. The path is
+	// made absolute, relative paths being relative to the working directory,
+	// not to Root. With Resolve, the symbolic links of both are resolved
+	// before the check, so that a link can not escape Root.
+	Root string
+}
+
+func (p *Path) Set(str string) error {
+	path := filepath.Clean(ExpandHome(str))
+	if _, err := os.Stat(path); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%s: %w", str, err)
+		}
+		if p.Exist {
+			return fmt.Errorf("%s: no such file or directory", str)
+		}
+	}
+	var err error
+	if p.Abs || p.Resolve || p.Root != "" {
+		if path, err = filepath.Abs(path); err != nil {
+			return fmt.Errorf("%s: %w", str, err)
+		}
+	}
+	if p.Resolve {
+		if path, err = resolvePath(path); err != nil {
+			return fmt.Errorf("%s: %w", str, err)
+		}
+	}
+	if p.Root != "" {
+		root, err := filepath.Abs(ExpandHome(p.Root))
+		if err == nil && p.Resolve {
+			root, err = resolvePath(root)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.Root, err)
+		}
+		if !within(root, path) {
+			return fmt.Errorf("%s: not under %s", str, p.Root)
+		}
+	}
+	p.Path = path
+	return nil
+}
+
+func (p *Path) reset(def string) {
+	p.Path = def
+}
+
+func (p *Path) String() string {
+	if p == nil {
+		return ""
+	}
+	return p.Path
+}
+
+func (p *Path) Type() string {
+	return "path"
+}
+
+// resolvePath evaluates the symbolic links of path. When path does not exist,
+// the links of its longest existing parent are, and so is the link path may
+// be even when its target is missing, so that a dangling link is taken for
+// the place it points to.
+func resolvePath(path string) (string, error) {
+	return resolveLinks(path, 255)
+}
+
+func resolveLinks(path string, hops int) (string, error) {
+	res, err := filepath.EvalSymlinks(path)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return res, err
+	}
+	dir, base := filepath.Split(path)
+	dir = filepath.Clean(dir)
+	if dir == path {
+		return path, nil
+	}
+	if dir, err = resolveLinks(dir, hops); err != nil {
+		return "", err
+	}
+	file := filepath.Join(dir, base)
+	i, err := os.Lstat(file)
+	if err != nil || i.Mode()&fs.ModeSymlink == 0 {
+		return file, nil
+	}
+	if hops == 0 {
+		return "", fmt.Errorf("%s: too many links", path)
+	}
+	target, err := os.Readlink(file)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(dir, target)
+	}
+	return resolveLinks(filepath.Clean(target), hops-1)
+}
+
+// within reports whether path is root or under it, both being absolute.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
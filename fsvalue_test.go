@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestPathRoot(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need privileges on windows")
+	}
+	var (
+		dir  = t.TempDir()
+		root = filepath.Join(dir, "root")
+	)
+	if err := os.MkdirAll(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "outside"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"escape":   filepath.Join(dir, "outside"),
+		"dangling": filepath.Join(dir, "outside-missing"),
+		"relative": "../outside-missing",
+		"chain":    "dangling",
+		"inside":   "sub/missing",
+		"loop":     "loop",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		file string
+		ok   bool
+	}{
+		{file: "sub", ok: true},
+		{file: "sub/new-file", ok: true},
+		{file: "inside", ok: true},
+		{file: "missing/deeper", ok: true},
+		{file: "../outside", ok: false},
+		{file: "escape", ok: false},
+		{file: "dangling", ok: false},
+		{file: "relative", ok: false},
+		{file: "chain", ok: false},
+		{file: "loop", ok: false},
+	}
+	for _, tt := range tests {
+		p := Path{Root: root, Resolve: true}
+		err := p.Set(filepath.Join(root, filepath.FromSlash(tt.file)))
+		if tt.ok && err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.file, err)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("%s: resolved to %s, outside of the root", tt.file, p.Path)
+		}
+	}
+}
+
+func TestFileArgContext(t *testing.T) {
+	var (
+		read error
+		cmd  = Command{
+			Usage: "apply <file>",
+			Args:  []Argument{Arg("file", Input)},
+		}
+	)
+	cmd.Run = func(c *Command, args []string) error {
+		if err := c.Parse(args); err != nil {
+			return err
+		}
+		f := c.Value("file").(*File)
+		_, read = f.Read(make([]byte, 1))
+		return nil
+	}
+	var (
+		out bytes.Buffer
+		app = App{Main: &cmd, Stdout: &out, Stderr: &out}
+	)
+	if err := app.Run([]string{"--offline", "http://127.0.0.1:1/patch.json"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !errors.Is(read, ErrOffline) {
+		t.Errorf("got %v, want ErrOffline when reading the argument offline", read)
+	}
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+)
+
+type jobsKey struct{}
+
+// Jobs returns the number of tasks that can run in parallel: the value given
+// with the --jobs flag of the command or the number of CPUs.
+func Jobs(ctx context.Context) int {
+	if n, ok := ctx.Value(jobsKey{}).(int); ok && n > 0 {
+		return n
+	}
+	return runtime.NumCPU()
+}
+
+func withJobs(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, jobsKey{}, n)
+}
+
+// TaskGroup runs labeled tasks concurrently, at most Jobs(ctx) at a time. The
+// context given to the tasks is cancelled as soon as one of them fails.
+type TaskGroup struct {
+	// Progress, when set, receives a line each time a task completes.
+	Progress io.Writer
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	sema   chan struct{}
+	wg     sync.WaitGroup
+
+	mu    sync.Mutex
+	errs  Errors
+	total int
+	done  int
+}
+
+func Group(ctx context.Context) *TaskGroup {
+	ctx, cancel := context.WithCancel(ctx)
+	return &TaskGroup{
+		ctx:    ctx,
+		cancel: cancel,
+		sema:   make(chan struct{}, Jobs(ctx)),
+	}
+}
+
+func (g *TaskGroup) Go(label string, fn func(context.Context) error) {
+	g.mu.Lock()
+	g.total++
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		select {
+		case g.sema <- struct{}{}:
+		case <-g.ctx.Done():
+			g.complete(label, 0, g.ctx.Err())
+			return
+		}
+		defer func() { <-g.sema }()
+
+		now := ClockFrom(g.ctx).Now()
+		err := fn(g.ctx)
+		g.complete(label, since(g.ctx, now), err)
+	}()
+}
+
+// Wait waits for all the tasks to complete and returns the errors of the
+// failed tasks as Errors. Tasks aborted because of the failure of another
+// task are not reported.
+func (g *TaskGroup) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.errs.Err()
+}
+
+func (g *TaskGroup) complete(label string, elapsed time.Duration, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.done++
+	cancelled := errors.Is(err, context.Canceled) && g.ctx.Err() != nil
+	if err != nil && !(cancelled && len(g.errs) > 0) {
+		g.errs = append(g.errs, fmt.Errorf("%s: %w", label, err))
+		g.cancel()
+	}
+	if g.Progress == nil {
+		return
+	}
+	switch {
+	case err == nil:
+		fmt.Fprintf(g.Progress, "[%d/%d] %s: done (%s)\n", g.done, g.total, label, elapsed.Round(time.Millisecond))
+	case cancelled:
+		fmt.Fprintf(g.Progress, "[%d/%d] %s: cancelled\n", g.done, g.total, label)
+	default:
+		fmt.Fprintf(g.Progress, "[%d/%d] %s: failed: %s\n", g.done, g.total, label, err)
+	}
+}
+
+// WorkerPool runs functions concurrently, at most n at a time. The context
+// given to the functions is cancelled as soon as one of them fails or when
+// the process receives an interrupt signal, which then no longer exits the
+// process until Wait returns.
+type WorkerPool struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	stop   func()
+	sema   chan struct{}
+	wg     sync.WaitGroup
+
+	once sync.Once
+	err  error
+}
+
+// Workers returns a WorkerPool running at most n functions at a time. If n is
+// zero or negative, it uses Jobs(ctx).
+func Workers(ctx context.Context, n int) *WorkerPool {
+	if n <= 0 {
+		n = Jobs(ctx)
+	}
+	ctx, stop := watchSignals(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	return &WorkerPool{
+		ctx:    ctx,
+		cancel: cancel,
+		stop:   stop,
+		sema:   make(chan struct{}, n),
+	}
+}
+
+// Go runs fn in a new goroutine. It blocks while n functions are running and
+// does not run fn if the context of the pool is already cancelled.
+func (w *WorkerPool) Go(fn func(context.Context) error) {
+	if err := w.ctx.Err(); err != nil {
+		w.fail(err)
+		return
+	}
+	select {
+	case w.sema <- struct{}{}:
+	case <-w.ctx.Done():
+		w.fail(w.ctx.Err())
+		return
+	}
+	w.wg.Add(1)
+	go func() {
+		defer func() {
+			<-w.sema
+			w.wg.Done()
+		}()
+		if err := fn(w.ctx); err != nil {
+			w.fail(err)
+		}
+	}()
+}
+
+// Wait waits for the running functions and returns the first error.
+func (w *WorkerPool) Wait() error {
+	w.wg.Wait()
+	w.cancel()
+	w.stop()
+	return w.err
+}
+
+func (w *WorkerPool) fail(err error) {
+	w.once.Do(func() {
+		w.err = err
+		w.cancel()
+	})
+}
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+)
+
+const appTemplate = `{{tr "usage"}}: {{.Name}} <command> [<args>]
+
+{{tr "commands"}}:
+{{range .Commands}}  {{.String}}	{{tr .Short}}
+{{end}}
+{{printf (tr "run %q for more information about a command") (printf "%s help <command>" .Name)}}
+`
+
+const commandTemplate = `{{if .Desc}}{{.Desc}}{{else}}{{.Short}}{{end}}
+
+{{tr "usage"}}: {{.Usage}}
+{{- if .Alias}}
+
+{{tr "aliases"}}: {{join .Alias ", "}}
+{{- end}}
+{{- if .Flags}}
+
+{{tr "options"}}:
+{{- range .Flags}}
+  -{{.Name}}{{if and (ne .Type "bool") (ne .Type "")}} {{.Type}}{{end}}	{{.Usage}}{{if and .Default (ne .Default "false") (ne .Default "0")}} ({{tr "default"}}: {{.Default}}){{end}}
+{{- end}}
+{{- end}}
+{{- if .Examples}}
+
+{{tr "examples"}}:
+{{- range .Examples}}
+{{- if .Desc}}
+  # {{.Desc}}
+{{- end}}
+  $ {{.Cmd}}
+{{- end}}
+{{- end}}
+`
+
+var templateFuncs = template.FuncMap{
+	"join":     strings.Join,
+	"tr":       Translate,
+	"count":    HumanCount,
+	"duration": HumanDuration,
+	"percent":  HumanPercent,
+	"size":     templateSize,
+}
+
+// templateSize formats v, a Size or an integer giving a number of bytes, for
+// the size function of the templates.
+func templateSize(v interface{}) (string, error) {
+	var s Size
+	switch v := v.(type) {
+	case Size:
+		s = v
+	case int:
+		s = Size(v)
+	case int8:
+		s = Size(v)
+	case int16:
+		s = Size(v)
+	case int32:
+		s = Size(v)
+	case int64:
+		s = Size(v)
+	case uint:
+		s = Size(v)
+	case uint8:
+		s = Size(v)
+	case uint16:
+		s = Size(v)
+	case uint32:
+		s = Size(v)
+	case uint64:
+		s = Size(v)
+	default:
+		return "", fmt.Errorf("size: unexpected %T", v)
+	}
+	return s.Human(IEC), nil
+}
+
+// contextFuncs returns the functions of the templates rendered for ctx, where
+// ago measures the time on the clock of ctx.
+func contextFuncs(ctx context.Context) template.FuncMap {
+	funcs := make(template.FuncMap, len(templateFuncs))
+	for name, fn := range templateFuncs {
+		funcs[name] = fn
+	}
+	funcs["ago"] = func(t time.Time) string {
+		return HumanSince(t, ClockFrom(ctx).Now())
+	}
+	return funcs
+}
+
+func renderTemplate(ctx context.Context, w io.Writer, name, text string, data interface{}) {
+	t, err := template.New(name).Funcs(contextFuncs(ctx)).Parse(text)
+	if err != nil {
+		fmt.Fprintf(w, "%s: invalid help template: %s\n", name, err)
+		return
+	}
+	tw := tabwriter.NewWriter(w, 12, 2, 2, ' ', 0)
+	if err := t.Execute(tw, data); err != nil {
+		fmt.Fprintf(tw, "\n%s: %s\n", name, err)
+	}
+	tw.Flush()
+}
+
+func (a *App) help(w io.Writer, args []string) {
+	ctx := withClock(context.Background(), a.Clock)
+	if len(args) > 0 {
+		if c := a.lookup(args[0]); c != nil {
+			c.printHelp(ctx, w)
+			return
+		}
+		fmt.Fprintf(w, "%s: unknown command\n", args[0])
+		return
+	}
+	var cs []*Command
+	for _, c := range a.Commands {
+		if c.Runnable() {
+			cs = append(cs, c)
+		}
+	}
+	data := struct {
+		Name     string
+		Commands []*Command
+	}{
+		Name:     a.name(),
+		Commands: cs,
+	}
+	tpl := a.Template
+	if tpl == "" {
+		tpl = appTemplate
+	}
+	renderTemplate(ctx, w, "help", tpl, data)
+}
+
+func (c *Command) printHelp(ctx context.Context, w io.Writer) {
+	tpl := c.Template
+	if tpl == "" {
+		tpl = commandTemplate
+	}
+	renderTemplate(ctx, w, c.String(), tpl, c.describe().translate(Locale()))
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"io"
+	"sort"
+)
+
+// SizeBucket is a range of sizes of a SizeHistogram, from Min included to Max
+// excluded. Max is zero for the last bucket.
+type SizeBucket struct {
+	Range string `json:"range"`
+	Min   Size   `json:"min"`
+	Max   Size   `json:"max"`
+	Count int64  `json:"count"`
+	Total Size   `json:"total"`
+}
+
+// SizeHistogram counts sizes, like the ones of files, in ranges.
+type SizeHistogram struct {
+	// Bounds are the limits between the ranges. By default, they go from 1KB
+	// to 1TB, each ten times the previous one, in the units of Base.
+	Bounds []Size
+	// Base selects the units of the labels of the ranges. It should not
+	// change once sizes are added.
+	Base SizeBase
+
+	counts []int64
+	totals []Size
+}
+
+// Add counts s in its range.
+func (h *SizeHistogram) Add(s Size) {
+	bounds := h.bounds()
+	if h.counts == nil {
+		h.counts = make([]int64, len(bounds)+1)
+		h.totals = make([]Size, len(bounds)+1)
+	}
+	i := sort.Search(len(bounds), func(i int) bool {
+		return s < bounds[i]
+	})
+	h.counts[i]++
+	h.totals[i] += s
+}
+
+// Buckets returns the ranges of h with their counts, from the smallest sizes.
+// The ranges before the first and after the last one with sizes are left out.
+func (h *SizeHistogram) Buckets() []SizeBucket {
+	var (
+		bounds = h.bounds()
+		list   []SizeBucket
+	)
+	for i := range h.counts {
+		var b SizeBucket
+		if i > 0 {
+			b.Min = bounds[i-1]
+		}
+		if i < len(bounds) {
+			b.Max = bounds[i]
+		}
+		b.Count, b.Total = h.counts[i], h.totals[i]
+		switch {
+		case i == 0:
+			b.Range = "<" + b.Max.Human(h.Base)
+		case i == len(bounds):
+			b.Range = ">=" + b.Min.Human(h.Base)
+		default:
+			b.Range = b.Min.Human(h.Base) + "-" + b.Max.Human(h.Base)
+		}
+		list = append(list, b)
+	}
+	for len(list) > 0 && list[0].Count == 0 {
+		list = list[1:]
+	}
+	for len(list) > 0 && list[len(list)-1].Count == 0 {
+		list = list[:len(list)-1]
+	}
+	return list
+}
+
+// Render prints the distribution of the sizes as a table with a bar of at
+// most width characters for each range.
+func (h *SizeHistogram) Render(w io.Writer, width int) error {
+	if width <= 0 {
+		width = 40
+	}
+	list := h.Buckets()
+	var max int64
+	for _, b := range list {
+		if b.Count > max {
+			max = b.Count
+		}
+	}
+	p, err := NewPrinter(w, "text")
+	if err != nil {
+		return err
+	}
+	for _, b := range list {
+		row := struct {
+			Range string `json:"range"`
+			Count string `json:"count"`
+			Total string `json:"total"`
+			Bar   string `json:"distribution"`
+		}{
+			Range: b.Range,
+			Count: HumanCount(b.Count),
+			Total: b.Total.Human(h.Base),
+			Bar:   barString(float64(b.Count), float64(max), width),
+		}
+		if err := p.Print(row); err != nil {
+			return err
+		}
+	}
+	return p.Flush()
+}
+
+func (h *SizeHistogram) bounds() []Size {
+	if len(h.Bounds) > 0 {
+		return h.Bounds
+	}
+	var (
+		bounds []Size
+		units  = []Size{KiB, MiB, GiB, TiB}
+	)
+	if h.Base == SI {
+		units = []Size{KB, MB, GB, TB}
+	}
+	for _, u := range units {
+		bounds = append(bounds, u, 10*u, 100*u)
+	}
+	return bounds[:len(bounds)-2]
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// HistoryFailedWarning is the ID of the warning printed when a command can
+// not be recorded in the history.
+const HistoryFailedWarning = "history-failed"
+
+// History counts the invocations of the commands of an app in a file. The
+// counts are used to rank the suggestions given for unknown commands.
+type History struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// DefaultHistory returns a History saved in the cache directory of the user.
+func DefaultHistory() *History {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	name := programName()
+	return &History{
+		Path: filepath.Join(dir, name, "history.json"),
+	}
+}
+
+func (h *History) Record(cmd string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	counts, err := h.load()
+	if err != nil {
+		return err
+	}
+	counts[cmd]++
+	buf, err := json.Marshal(counts)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.Path), 0700); err != nil {
+		return err
+	}
+	return WriteFileAtomic(h.Path, buf, 0600)
+}
+
+func (h *History) Counts() (map[string]int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.load()
+}
+
+func (h *History) load() (map[string]int, error) {
+	counts := make(map[string]int)
+	buf, err := os.ReadFile(h.Path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+		return counts, err
+	}
+	return counts, json.Unmarshal(buf, &counts)
+}
+
+// editDistance is the Levenshtein distance between a and b, used to find the
+// suggestions that are equally close.
+func editDistance(a, b string) int {
+	var (
+		ra   = []rune(a)
+		rb   = []rune(b)
+		prev = make([]int, len(rb)+1)
+		curr = make([]int, len(rb)+1)
+	)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j] + 1
+			if v := curr[j-1] + 1; v < curr[j] {
+				curr[j] = v
+			}
+			if v := prev[j-1] + cost; v < curr[j] {
+				curr[j] = v
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
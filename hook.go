@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// HookFailedWarning is the ID of the warning printed when a post-exec hook
+// fails.
+const HookFailedWarning = "hook-failed"
+
+type hookKey struct{}
+
+// postHook returns the post-exec hook of c configured by the user under the
+// hooks.<command>.post key. Commands run by Invoke or Capture have no hook.
+//
+// Hooks are run by the shell, so they are only read from the configuration
+// file of the user: the ones of the project files, found in the current
+// directory and its parents, would let any cloned repository run commands.
+func postHook(ctx context.Context, c *Command) string {
+	if _, nested := ctx.Value(recorderKey{}).(*recorder); nested {
+		return ""
+	}
+	a := appFrom(ctx)
+	if a == nil || a.Config == "" {
+		return ""
+	}
+	var (
+		cfg = ConfigFrom(ctx)
+		key = "hooks." + c.String() + ".post"
+	)
+	if cfg.Origin(key) != userConfigFile(a.Config) {
+		return ""
+	}
+	return cfg.Get(key)
+}
+
+func hookRecorderFrom(ctx context.Context) *recorder {
+	r, _ := ctx.Value(hookKey{}).(*recorder)
+	return r
+}
+
+// runHook runs the shell command hook with the records printed by c, as a
+// JSON array, on its standard input. A failure of the hook is only reported
+// as a warning since the command itself succeeded.
+func (a *App) runHook(ctx context.Context, c *Command, hook string, records []interface{}) {
+	if records == nil {
+		records = []interface{}{}
+	}
+	buf, err := json.Marshal(records)
+	if err == nil {
+		err = Do(ctx, "run hook "+hook, func() error {
+			cmd := shellCommand(ctx, hook)
+			cmd.Env = append(os.Environ(), "CLI_COMMAND="+c.String())
+			cmd.Stdin = bytes.NewReader(buf)
+			cmd.Stdout = a.stdout()
+			cmd.Stderr = a.stderr()
+			return cmd.Run()
+		})
+	}
+	if err != nil {
+		Warn(ctx, a.stderr(), HookFailedWarning, "hook of %s failed: %s", c, err)
+	}
+}
+
+func shellCommand(ctx context.Context, line string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", line)
+	}
+	return exec.CommandContext(ctx, "/bin/sh", "-c", line)
+}
+
+// teePrinter records what it prints for the post-exec hook of the command.
+type teePrinter struct {
+	Printer
+	hook *recorder
+}
+
+func (t teePrinter) Print(record interface{}) error {
+	t.hook.Print(record)
+	return t.Printer.Print(record)
+}
@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HTTPClient bundles the options shared by the HTTP clients of an
+// application.
+type HTTPClient struct {
+	Timeout   time.Duration
+	UserAgent string
+	Cache     *Cache
+	Auth      *Auth
+	Transport http.RoundTripper
+
+	// Proxy overrides the proxy given by the HTTP_PROXY, HTTPS_PROXY and
+	// NO_PROXY environment variables.
+	Proxy string
+	// ProxyDebug traces on stderr the proxy selected for each request.
+	ProxyDebug bool
+	// Debug logs the requests sent and the responses received with their
+	// headers, credentials being redacted.
+	Debug  bool
+	Logger *log.Logger
+}
+
+// Flags registers in set the flags controlling the options of h.
+func (h *HTTPClient) Flags(set *flag.FlagSet) {
+	set.StringVar(&h.Proxy, "proxy", h.Proxy, "proxy URL used for HTTP requests")
+	set.BoolVar(&h.ProxyDebug, "proxy-debug", h.ProxyDebug, "show the proxy selected for HTTP requests")
+	set.BoolVar(&h.Debug, "http-debug", h.Debug, "log HTTP requests and responses")
+}
+
+// Client builds a http.Client from the options of h.
+func (h HTTPClient) Client() *http.Client {
+	rt := h.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if t, ok := rt.(*http.Transport); ok {
+		t = t.Clone()
+		t.Proxy = h.proxy
+		rt = t
+	}
+	rt = offlineTransport{
+		inner: rt,
+	}
+	if h.Cache != nil {
+		rt = CacheTransport{
+			Cache:     h.Cache,
+			Transport: rt,
+		}
+	}
+	if h.Debug {
+		rt = debugTransport{
+			logger: h.logger(),
+			inner:  rt,
+		}
+	}
+	if h.Auth != nil {
+		rt = h.Auth.Transport(rt)
+	}
+	rt = agentTransport{
+		agent: h.userAgent(),
+		inner: rt,
+	}
+	return &http.Client{
+		Timeout:   h.Timeout,
+		Transport: rt,
+	}
+}
+
+func (h HTTPClient) proxy(req *http.Request) (*url.URL, error) {
+	var (
+		proxy  *url.URL
+		err    error
+		source = "environment"
+	)
+	if h.Proxy != "" {
+		source = "--proxy"
+		proxy, err = url.Parse(h.Proxy)
+		if err == nil && proxy.Scheme == "" {
+			proxy, err = url.Parse("http://" + h.Proxy)
+		}
+	} else {
+		proxy, err = http.ProxyFromEnvironment(req)
+	}
+	if h.ProxyDebug {
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "proxy: %s %s: %s\n", req.Method, req.URL, err)
+		case proxy == nil:
+			fmt.Fprintf(os.Stderr, "proxy: %s %s: direct connection\n", req.Method, req.URL)
+		default:
+			fmt.Fprintf(os.Stderr, "proxy: %s %s: via %s (%s)\n", req.Method, req.URL, redactURL(proxy), source)
+		}
+	}
+	return proxy, err
+}
+
+func redactURL(u *url.URL) string {
+	if u.User == nil {
+		return u.String()
+	}
+	c := *u
+	c.User = url.User(u.User.Username())
+	return c.String()
+}
+
+func (h HTTPClient) logger() *log.Logger {
+	if h.Logger != nil {
+		return h.Logger
+	}
+	return log.New(os.Stderr, "http: ", log.LstdFlags)
+}
+
+func (h HTTPClient) userAgent() string {
+	if h.UserAgent != "" {
+		return h.UserAgent
+	}
+	agent := programName()
+	if Version != "" {
+		agent += "/" + Version
+	}
+	return agent
+}
+
+type agentTransport struct {
+	agent string
+	inner http.RoundTripper
+}
+
+func (t agentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") != "" {
+		return t.inner.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.agent)
+	return t.inner.RoundTrip(req)
+}
+
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+type debugTransport struct {
+	logger *log.Logger
+	inner  http.RoundTripper
+}
+
+func (t debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target := redactURL(req.URL)
+	t.logger.Printf("> %s %s%s", req.Method, target, formatHeaders(req.Header))
+
+	now := ClockFrom(req.Context()).Now()
+	res, err := t.inner.RoundTrip(req)
+	elapsed := since(req.Context(), now).Round(time.Millisecond)
+	if err != nil {
+		t.logger.Printf("< %s %s: %s (%s)", req.Method, target, err, elapsed)
+		return nil, err
+	}
+	t.logger.Printf("< %s %s: %s (%s)%s", req.Method, target, res.Status, elapsed, formatHeaders(res.Header))
+	return res, err
+}
+
+func formatHeaders(h http.Header) string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf strings.Builder
+	for _, k := range keys {
+		for _, v := range h[k] {
+			buf.WriteString("\n  ")
+			buf.WriteString(k)
+			buf.WriteString(": ")
+			buf.WriteString(redactHeader(k, v))
+		}
+	}
+	return buf.String()
+}
+
+func redactHeader(key, value string) string {
+	for _, s := range sensitiveHeaders {
+		if !strings.EqualFold(s, key) {
+			continue
+		}
+		if i := strings.Index(value, " "); i > 0 && strings.HasSuffix(key, "Authorization") {
+			return value[:i] + " ********"
+		}
+		return "********"
+	}
+	return value
+}
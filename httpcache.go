@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const cacheHeader = "X-Cache"
+
+// CacheTransport caches the responses to GET requests. Fresh responses (per
+// their Cache-Control max-age) are served from the cache; stale ones are
+// revalidated with If-None-Match and If-Modified-Since. When the server can
+// not be reached, the cached response is served whatever its age.
+//
+// Responses served from the cache have their X-Cache header set to HIT.
+type CacheTransport struct {
+	Cache     *Cache
+	Transport http.RoundTripper
+}
+
+func (t CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.transport().RoundTrip(req)
+	}
+	var (
+		key           = cacheKey(req)
+		cached, stamp = t.load(key, req)
+	)
+	if cached != nil && fresh(req.Context(), cached, stamp) && !noCache(req.Header) {
+		return hit(cached), nil
+	}
+	if cached != nil {
+		req = req.Clone(req.Context())
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if mod := cached.Header.Get("Last-Modified"); mod != "" {
+			req.Header.Set("If-Modified-Since", mod)
+		}
+	}
+	res, err := t.transport().RoundTrip(req)
+	if err != nil {
+		if cached != nil {
+			return hit(cached), nil
+		}
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotModified && cached != nil {
+		res.Body.Close()
+		for _, k := range []string{"Cache-Control", "Date", "ETag", "Expires", "Last-Modified"} {
+			if v := res.Header.Get(k); v != "" {
+				cached.Header.Set(k, v)
+			}
+		}
+		t.store(req.Context(), key, cached)
+		return hit(cached), nil
+	}
+	if res.StatusCode == http.StatusOK && storable(res) {
+		t.store(req.Context(), key, res)
+	}
+	return res, nil
+}
+
+func (t CacheTransport) transport() http.RoundTripper {
+	if t.Transport == nil {
+		return http.DefaultTransport
+	}
+	return t.Transport
+}
+
+func (t CacheTransport) load(key string, req *http.Request) (*http.Response, time.Time) {
+	buf, err := t.Cache.Get(key)
+	if err != nil {
+		return nil, time.Time{}
+	}
+	rs := bufio.NewReader(bytes.NewReader(buf))
+	line, err := rs.ReadString('\n')
+	if err != nil {
+		return nil, time.Time{}
+	}
+	when, _ := strconv.ParseInt(strings.TrimSpace(line), 10, 64)
+	res, err := http.ReadResponse(rs, req)
+	if err != nil {
+		return nil, time.Time{}
+	}
+	return res, time.Unix(when, 0)
+}
+
+func (t CacheTransport) store(ctx context.Context, key string, res *http.Response) {
+	dump, err := httputil.DumpResponse(res, true)
+	if err != nil {
+		return
+	}
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, ClockFrom(ctx).Now().Unix())
+	buf.Write(dump)
+	t.Cache.Put(key, buf.Bytes())
+}
+
+func cacheKey(req *http.Request) string {
+	return req.Method + " " + req.URL.String() + " " + req.Header.Get("Authorization")
+}
+
+func hit(res *http.Response) *http.Response {
+	res.Header.Set(cacheHeader, "HIT")
+	return res
+}
+
+func fresh(ctx context.Context, res *http.Response, stamp time.Time) bool {
+	age, ok := maxAge(res.Header)
+	return ok && since(ctx, stamp) < age
+}
+
+func storable(res *http.Response) bool {
+	cc := res.Header.Get("Cache-Control")
+	if strings.Contains(cc, "no-store") {
+		return false
+	}
+	_, ok := maxAge(res.Header)
+	return ok || res.Header.Get("ETag") != "" || res.Header.Get("Last-Modified") != ""
+}
+
+func noCache(h http.Header) bool {
+	cc := h.Get("Cache-Control")
+	return strings.Contains(cc, "no-cache") || strings.Contains(cc, "max-age=0")
+}
+
+func maxAge(h http.Header) (time.Duration, bool) {
+	cc := h.Get("Cache-Control")
+	if strings.Contains(cc, "no-cache") {
+		return 0, false
+	}
+	for _, d := range strings.Split(cc, ",") {
+		d = strings.TrimSpace(d)
+		if !strings.HasPrefix(d, "max-age=") {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(d, "max-age="))
+		if err != nil {
+			return 0, false
+		}
+		return time.Duration(n) * time.Second, true
+	}
+	return 0, false
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HumanCount formats n with a metric suffix: 950, 1.2k, 3.4M, 5G...
+func HumanCount(n int64) string {
+	const units = "kMGTPE"
+	var (
+		value = math.Abs(float64(n))
+		i     = -1
+	)
+	for i < len(units)-1 && math.Round(value*10)/10 >= 1000 {
+		value /= 1000
+		i++
+	}
+	if i < 0 {
+		return strconv.FormatInt(n, 10)
+	}
+	str := trimDecimals(strconv.FormatFloat(value, 'f', 1, 64))
+	if n < 0 {
+		str = "-" + str
+	}
+	return str + string(units[i])
+}
+
+// HumanDuration formats d with at most two units, the largest ones being days
+// and weeks: 45s, 2m30s, 3h, 1d4h, 2w.
+func HumanDuration(d time.Duration) string {
+	if d < 0 {
+		return "-" + HumanDuration(-d)
+	}
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	units := []struct {
+		unit time.Duration
+		name string
+	}{
+		{7 * 24 * time.Hour, "w"},
+		{24 * time.Hour, "d"},
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+		{time.Second, "s"},
+	}
+	var (
+		buf  strings.Builder
+		used int
+	)
+	d = d.Round(time.Second)
+	for _, u := range units {
+		if d < u.unit && used == 0 {
+			continue
+		}
+		n := d / u.unit
+		d -= n * u.unit
+		if n > 0 {
+			buf.WriteString(strconv.FormatInt(int64(n), 10))
+			buf.WriteString(u.name)
+		}
+		if used++; used == 2 {
+			break
+		}
+	}
+	return buf.String()
+}
+
+// HumanSince describes t relatively to now, like "2h ago" or "in 3d".
+func HumanSince(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d > -time.Second && d < time.Second:
+		return "now"
+	case d < 0:
+		return "in " + HumanDuration(-d)
+	default:
+		return HumanDuration(d) + " ago"
+	}
+}
+
+// HumanPercent formats the ratio of part to total as a percentage with at
+// most one decimal.
+func HumanPercent(part, total float64) string {
+	if total == 0 {
+		return "0%"
+	}
+	str := strconv.FormatFloat(part*100/total, 'f', 1, 64)
+	return trimDecimals(str) + "%"
+}
+
+func trimDecimals(str string) string {
+	if !strings.Contains(str, ".") {
+		return str
+	}
+	return strings.TrimRight(strings.TrimRight(str, "0"), ".")
+}
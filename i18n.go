@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+var catalogs struct {
+	sync.RWMutex
+	set map[string]map[string]string
+}
+
+// RegisterCatalog registers the translations of messages, keyed by their
+// original text, for the language lang, like "fr" or "pt_BR". Registering
+// a catalog for a language already registered adds to its messages.
+func RegisterCatalog(lang string, messages map[string]string) {
+	catalogs.Lock()
+	defer catalogs.Unlock()
+	if catalogs.set == nil {
+		catalogs.set = make(map[string]map[string]string)
+	}
+	lang = normalizeLocale(lang)
+	set, ok := catalogs.set[lang]
+	if !ok {
+		set = make(map[string]string)
+		catalogs.set[lang] = set
+	}
+	for k, v := range messages {
+		set[k] = v
+	}
+}
+
+// Locale returns the language selected by the LC_ALL, LC_MESSAGES and LANG
+// environment variables, in this order, without encoding: fr_BE.UTF-8 gives
+// fr_BE.
+func Locale() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if str := os.Getenv(env); str != "" {
+			return normalizeLocale(str)
+		}
+	}
+	return ""
+}
+
+// Translate returns the translation of msg for the current locale, falling
+// back to the language without its region and then to msg itself.
+func Translate(msg string) string {
+	return TranslateTo(Locale(), msg)
+}
+
+// TranslateTo is like Translate but for the language lang.
+func TranslateTo(lang, msg string) string {
+	if msg == "" {
+		return msg
+	}
+	lang = normalizeLocale(lang)
+	if lang == "" || lang == "C" || lang == "POSIX" {
+		return msg
+	}
+	catalogs.RLock()
+	defer catalogs.RUnlock()
+	for {
+		if str, ok := catalogs.set[lang][msg]; ok {
+			return str
+		}
+		i := strings.IndexAny(lang, "_-")
+		if i < 0 {
+			return msg
+		}
+		lang = lang[:i]
+	}
+}
+
+func normalizeLocale(str string) string {
+	if i := strings.IndexAny(str, ".@"); i >= 0 {
+		str = str[:i]
+	}
+	return strings.Replace(str, "-", "_", -1)
+}
+
+// Translate returns a copy of s with its texts translated for the language
+// lang, for instance to write localized pages with WriteDocs.
+func (s Spec) Translate(lang string) Spec {
+	cs := make([]CommandSpec, len(s.Commands))
+	for i, c := range s.Commands {
+		cs[i] = c.translate(lang)
+	}
+	s.Commands = cs
+	s.Lang = normalizeLocale(lang)
+	return s
+}
+
+func (s CommandSpec) translate(lang string) CommandSpec {
+	s.Usage = TranslateTo(lang, s.Usage)
+	s.Short = TranslateTo(lang, s.Short)
+	s.Desc = TranslateTo(lang, s.Desc)
+	flags := make([]FlagSpec, len(s.Flags))
+	for i, f := range s.Flags {
+		f.Usage = TranslateTo(lang, f.Usage)
+		flags[i] = f
+	}
+	s.Flags = flags
+	examples := make([]Example, len(s.Examples))
+	for i, e := range s.Examples {
+		e.Desc = TranslateTo(lang, e.Desc)
+		examples[i] = e
+	}
+	s.Examples = examples
+	return s
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Ignore is a set of rules, in the syntax of gitignore, telling which files
+// and directories to leave out.
+type Ignore struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	pattern  []string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// ParseIgnore reads the rules of an ignore file from r.
+func ParseIgnore(r io.Reader) (*Ignore, error) {
+	var (
+		ig   Ignore
+		scan = bufio.NewScanner(r)
+	)
+	for scan.Scan() {
+		line := strings.TrimRight(scan.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate, line = true, line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly, line = true, strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored, line = true, strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = strings.Split(line, "/")
+		ig.rules = append(ig.rules, rule)
+	}
+	return &ig, scan.Err()
+}
+
+// LoadIgnore reads the rules of the ignore file. A file that does not exist
+// has no rule.
+func LoadIgnore(file string) (*Ignore, error) {
+	r, err := os.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Ignore{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ParseIgnore(r)
+}
+
+// Ignored reports whether rel, a path relative to the directory of the ignore
+// file with slashes as separators, is left out.
+func (i *Ignore) Ignored(rel string, dir bool) bool {
+	ignored, _ := i.match(rel, dir)
+	return ignored
+}
+
+// match returns the decision of the last rule matching rel, and whether any
+// did.
+func (i *Ignore) match(rel string, dir bool) (bool, bool) {
+	if i == nil {
+		return false, false
+	}
+	name := strings.Split(rel, "/")
+	for j := len(i.rules) - 1; j >= 0; j-- {
+		r := i.rules[j]
+		if r.dirOnly && !dir {
+			continue
+		}
+		ok := matchSegments(r.pattern, name)
+		if !r.anchored {
+			ok = matchSegments(r.pattern, name[len(name)-1:])
+		}
+		if ok {
+			return !r.negate, true
+		}
+	}
+	return false, false
+}
+
+func matchSegments(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			pat = pat[1:]
+			if len(pat) == 0 {
+				return len(name) > 0
+			}
+			for i := range name {
+				if matchSegments(pat, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], name[0]); !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// ignoreTree keeps the rules of the ignore files found in the directories of
+// a walk.
+type ignoreTree struct {
+	names []string
+
+	mu   sync.Mutex
+	dirs map[string]*Ignore
+}
+
+func newIgnoreTree(names []string) *ignoreTree {
+	return &ignoreTree{
+		names: names,
+		dirs:  make(map[string]*Ignore),
+	}
+}
+
+// load reads the ignore files of dir.
+func (t *ignoreTree) load(dir string) error {
+	var all Ignore
+	for _, n := range t.names {
+		ig, err := LoadIgnore(filepath.Join(dir, n))
+		if err != nil {
+			return err
+		}
+		all.rules = append(all.rules, ig.rules...)
+	}
+	if len(all.rules) > 0 {
+		t.mu.Lock()
+		t.dirs[dir] = &all
+		t.mu.Unlock()
+	}
+	return nil
+}
+
+// ignored reports whether file, found under root, is left out by the ignore
+// files of its parent directories, the deepest ones taking precedence.
+func (t *ignoreTree) ignored(root, file string, dir bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	parent := filepath.Dir(file)
+	for {
+		if ig, ok := t.dirs[parent]; ok {
+			rel, err := filepath.Rel(parent, file)
+			if err == nil {
+				if res, ok := ig.match(filepath.ToSlash(rel), dir); ok {
+					return res
+				}
+			}
+		}
+		if parent == root || parent == filepath.Dir(parent) {
+			break
+		}
+		parent = filepath.Dir(parent)
+	}
+	return false
+}
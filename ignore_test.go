@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnore(t *testing.T) {
+	const rules = `# build output
+*.o
+/bin
+build/
+!keep.o
+docs/**/*.tmp
+**/cache
+\#notes
+`
+	ig, err := ParseIgnore(strings.NewReader(rules))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tests := []struct {
+		rel  string
+		dir  bool
+		want bool
+	}{
+		{rel: "main.o", want: true},
+		{rel: "src/lib/util.o", want: true},
+		{rel: "keep.o", want: false},
+		{rel: "src/keep.o", want: false},
+		{rel: "main.go", want: false},
+		{rel: "bin", dir: true, want: true},
+		{rel: "src/bin", dir: true, want: false},
+		{rel: "build", dir: true, want: true},
+		{rel: "src/build", dir: true, want: true},
+		{rel: "build", dir: false, want: false},
+		{rel: "docs/a.tmp", want: true},
+		{rel: "src/docs/a.tmp", want: false},
+		{rel: "docs/x/a.tmp", want: true},
+		{rel: "docs/x/y/a.tmp", want: true},
+		{rel: "cache", dir: true, want: true},
+		{rel: "a/b/cache", dir: true, want: true},
+		{rel: "#notes", want: true},
+		{rel: "# build output", want: false},
+	}
+	for _, tt := range tests {
+		if got := ig.Ignored(tt.rel, tt.dir); got != tt.want {
+			t.Errorf("%s (dir: %t): got %t, want %t", tt.rel, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreNil(t *testing.T) {
+	var ig *Ignore
+	if ig.Ignored("main.o", false) {
+		t.Errorf("nil Ignore should not ignore anything")
+	}
+}
+
+func TestIgnoreTree(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".appignore":         "*.log\nvendor/\n",
+		"src/.appignore":     "!keep.log\ngen/\n",
+		"src/gen/.appignore": "!*.go\n",
+	}
+	for name, rules := range files {
+		file := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(rules), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tree := newIgnoreTree([]string{".appignore"})
+	for _, dir := range []string{"", "src", "src/gen"} {
+		if err := tree.load(filepath.Join(root, filepath.FromSlash(dir))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ignored := map[string]bool{
+		"debug.log":        true,
+		"src/debug.log":    true,
+		"src/keep.log":     false,
+		"keep.log":         true,
+		"src/gen/main.go":  false,
+		"src/main.go":      false,
+		"src/vendor/x.go":  false,
+		"src/gen/keep.log": false,
+	}
+	for rel, want := range ignored {
+		file := filepath.Join(root, filepath.FromSlash(rel))
+		if got := tree.ignored(root, file, false); got != want {
+			t.Errorf("%s: got %t, want %t", rel, got, want)
+		}
+	}
+	if !tree.ignored(root, filepath.Join(root, "vendor"), true) {
+		t.Errorf("vendor should be ignored by the rules of the root")
+	}
+	if !tree.ignored(root, filepath.Join(root, "src", "gen"), true) {
+		t.Errorf("src/gen should be ignored by the rules of src")
+	}
+}
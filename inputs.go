@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Patterns is a flag value collecting patterns in the syntax of
+// filepath.Match. It can be given multiple times.
+type Patterns []string
+
+func (p *Patterns) Set(str string) error {
+	if _, err := filepath.Match(str, ""); err != nil {
+		return fmt.Errorf("%s: %w", str, err)
+	}
+	*p = append(*p, str)
+	return nil
+}
+
+func (p *Patterns) String() string {
+	if p == nil {
+		return ""
+	}
+	return strings.Join(*p, ",")
+}
+
+func (p *Patterns) Type() string {
+	return "pattern"
+}
+
+// match reports whether one of the patterns matches file. Patterns with a
+// slash are matched against rel, the path relative to the directory given on
+// the command line, the others against the base name of file.
+func (p Patterns) match(file, rel string) bool {
+	for _, pat := range p {
+		name := filepath.Base(file)
+		if strings.Contains(pat, "/") {
+			name = filepath.ToSlash(rel)
+		}
+		if ok, _ := filepath.Match(pat, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// InputSet turns the arguments of a file processing command into the list of
+// its inputs. Arguments with wildcards that are not existing files are
+// expanded, for the shells that do not do it. Directories are walked when
+// Recursive is set, keeping the files matching Include, if not empty, and not
+// matching Exclude. Without argument, the input is the standard input unless
+// it is a terminal. The argument - also designates the standard input.
+//
+// While walking directories, the files and directories left out by the
+// ignore files found on the way are skipped, unless NoIgnore is set.
+type InputSet struct {
+	Recursive bool
+	Include   Patterns
+	Exclude   Patterns
+	NoIgnore  bool
+	// IgnoreFiles are the names of the ignore files, in the syntax of
+	// gitignore. They default to .gitignore and .<program>ignore.
+	IgnoreFiles []string
+	// Stdin defaults to os.Stdin.
+	Stdin io.Reader
+}
+
+// Flags registers in set the flags controlling the options of s.
+func (s *InputSet) Flags(set *flag.FlagSet) {
+	set.BoolVar(&s.Recursive, "r", s.Recursive, "process directories recursively")
+	set.Var(&s.Include, "include", "only process files matching pattern")
+	set.Var(&s.Exclude, "exclude", "skip files and directories matching pattern")
+	set.BoolVar(&s.NoIgnore, "no-ignore", s.NoIgnore, "do not respect the ignore files")
+}
+
+// Files returns the names of the inputs given by args, - being the standard
+// input.
+func (s *InputSet) Files(args []string) ([]string, error) {
+	if len(args) == 0 {
+		if f, ok := s.stdin().(*os.File); ok && IsTerminal(f) {
+			return nil, fmt.Errorf("no input files")
+		}
+		return []string{"-"}, nil
+	}
+	var files []string
+	for _, a := range args {
+		if a == "-" {
+			files = append(files, a)
+			continue
+		}
+		list, err := expandArg(ExpandHome(a))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range list {
+			if files, err = s.collect(files, file); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+// Each calls fn with the name and the content of each input given by args,
+// in order, and stops at the first error.
+func (s *InputSet) Each(args []string, fn func(name string, r io.Reader) error) error {
+	files, err := s.Files(args)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := s.open(file, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *InputSet) open(file string, fn func(string, io.Reader) error) error {
+	if file == "-" {
+		return fn(file, s.stdin())
+	}
+	r, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return fn(file, r)
+}
+
+func (s *InputSet) collect(files []string, file string) ([]string, error) {
+	i, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	if !i.IsDir() {
+		return append(files, file), nil
+	}
+	if !s.Recursive {
+		return nil, fmt.Errorf("%s: is a directory", file)
+	}
+	var ignore *ignoreTree
+	if !s.NoIgnore {
+		ignore = newIgnoreTree(s.ignoreFiles())
+	}
+	var (
+		mu    sync.Mutex
+		found []string
+		walk  Walker
+	)
+	err = walk.Walk(context.Background(), file, func(p string, e fs.DirEntry) error {
+		rel, _ := filepath.Rel(file, p)
+		skip := p != file && s.Exclude.match(p, rel)
+		if !skip && p != file && ignore != nil {
+			skip = ignore.ignored(file, p, e.IsDir())
+		}
+		if skip {
+			if e.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if e.IsDir() && ignore != nil {
+			if err := ignore.load(p); err != nil {
+				return err
+			}
+		}
+		if !e.Type().IsRegular() {
+			return nil
+		}
+		if len(s.Include) == 0 || s.Include.match(p, rel) {
+			mu.Lock()
+			found = append(found, p)
+			mu.Unlock()
+		}
+		return nil
+	})
+	walkOrder(found)
+	return append(files, found...), err
+}
+
+func (s *InputSet) ignoreFiles() []string {
+	if len(s.IgnoreFiles) > 0 {
+		return s.IgnoreFiles
+	}
+	return []string{".gitignore", "." + programName() + "ignore"}
+}
+
+func (s *InputSet) stdin() io.Reader {
+	if s.Stdin == nil {
+		return os.Stdin
+	}
+	return s.Stdin
+}
+
+// expandArg returns the files matching arg when it has wildcards and is not
+// the name of an existing file.
+func expandArg(arg string) ([]string, error) {
+	if !strings.ContainsAny(arg, "*?[") {
+		return []string{arg}, nil
+	}
+	if _, err := os.Lstat(arg); err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return []string{arg}, nil
+	}
+	list, err := filepath.Glob(arg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", arg, err)
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("%s: no match", arg)
+	}
+	return list, nil
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+type appKey struct{}
+
+type recorderKey struct{}
+
+// recorder is the Printer of the commands run by Capture.
+type recorder struct {
+	mu      sync.Mutex
+	records []interface{}
+}
+
+func (r *recorder) Print(record interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, record)
+	return nil
+}
+
+func (r *recorder) Flush() error {
+	return nil
+}
+
+func recorderFrom(ctx context.Context) *recorder {
+	r, _ := ctx.Value(recorderKey{}).(*recorder)
+	return r
+}
+
+func withRecorder(ctx context.Context, r *recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+func appFrom(ctx context.Context) *App {
+	a, _ := ctx.Value(appKey{}).(*App)
+	return a
+}
+
+func withApp(ctx context.Context, a *App) context.Context {
+	return context.WithValue(ctx, appKey{}, a)
+}
+
+// Invoke runs the command name of the app running the command owning ctx,
+// the way the app would have run it from the command line. The invoked
+// command inherits the deadline and the global options of ctx.
+//
+//	func deploy(c *cli.Command, args []string) error {
+//		for _, name := range []string{"build", "push", "apply"} {
+//			if err := cli.Invoke(c.Context(), name, args...); err != nil {
+//				return err
+//			}
+//		}
+//		return nil
+//	}
+func Invoke(ctx context.Context, name string, args ...string) error {
+	return invoke(withRecorder(ctx, nil), name, args)
+}
+
+// Capture runs the command name like Invoke and returns the records it
+// printed with its Printer instead of rendering them.
+//
+//	list, err := cli.Capture(c.Context(), "list", "-all")
+//	for _, r := range list {
+//		item := r.(Item)
+//		...
+//	}
+func Capture(ctx context.Context, name string, args ...string) ([]interface{}, error) {
+	var r recorder
+	err := invoke(withRecorder(ctx, &r), name, args)
+	return r.records, err
+}
+
+func invoke(ctx context.Context, name string, args []string) error {
+	a := appFrom(ctx)
+	if a == nil {
+		return fmt.Errorf("%s: can not invoke command outside of an app", name)
+	}
+	args, err := a.expand(ctx, append([]string{name}, args...))
+	if err != nil {
+		return err
+	}
+	c := a.lookup(args[0])
+	if c == nil {
+		return a.suggest(args[0])
+	}
+	if c.parent != nil {
+		return fmt.Errorf("%s: command already running", c)
+	}
+	return a.execute(ctx, c, args[1:])
+}
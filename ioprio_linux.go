@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"syscall"
+)
+
+const (
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+	ioprioClassBE    = 2
+	ioprioClassIdle  = 3
+)
+
+func setIOClass(class string) error {
+	prio := ioprioClassIdle << ioprioClassShift
+	if class == "best-effort" {
+		prio = ioprioClassBE<<ioprioClassShift | 4
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, 0, uintptr(prio))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+// +build !linux
+
+package cli
+
+func setIOClass(class string) error {
+	return errLimits
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+)
+
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema returns the JSON Schema of the configuration files described by
+// s, so that editors can complete and check them. Keys with dots are nested
+// objects and the keys marked as List are arrays. The sections used by the package itself, like alias and hooks,
+// are described too.
+func (s ConfigSchema) JSONSchema() map[string]interface{} {
+	root := map[string]interface{}{
+		"$schema":    jsonSchemaDraft,
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	}
+	props := root["properties"].(map[string]interface{})
+	props["alias"] = map[string]interface{}{
+		"description":          "commands standing for other command lines",
+		"type":                 "object",
+		"additionalProperties": map[string]interface{}{"type": "string"},
+	}
+	props["hooks"] = map[string]interface{}{
+		"description": "shell commands run after the commands",
+		"type":        "object",
+		"additionalProperties": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"post": map[string]interface{}{"type": "string"},
+			},
+		},
+	}
+	props["warnings"] = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"suppress": map[string]interface{}{
+				"description": "warnings not to print",
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+			},
+		},
+	}
+	for _, f := range s {
+		var (
+			parts = strings.Split(f.Key, ".")
+			obj   = root
+		)
+		for _, p := range parts[:len(parts)-1] {
+			props := obj["properties"].(map[string]interface{})
+			sub, ok := props[p].(map[string]interface{})
+			if !ok || sub["properties"] == nil {
+				sub = map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+				}
+				props[p] = sub
+			}
+			obj = sub
+		}
+		field := map[string]interface{}{
+			"type": []string{"string", "number", "boolean"},
+		}
+		if f.Desc != "" {
+			field["description"] = f.Desc
+		}
+		if len(f.Values) > 0 {
+			field["type"] = "string"
+			field["enum"] = f.Values
+		}
+		if f.Sensitive {
+			field["type"] = "string"
+			field["writeOnly"] = true
+		}
+		if f.List {
+			delete(field, "description")
+			field = map[string]interface{}{
+				"type":  "array",
+				"items": field,
+			}
+			if f.Desc != "" {
+				field["description"] = f.Desc
+			}
+		}
+		obj["properties"].(map[string]interface{})[parts[len(parts)-1]] = field
+	}
+	return root
+}
+
+// SpecSchema returns the JSON Schema of the description of the commands given
+// by Describe.
+func SpecSchema() map[string]interface{} {
+	schema := jsonSchemaOf(reflect.TypeOf(Spec{}))
+	schema["$schema"] = jsonSchemaDraft
+	return schema
+}
+
+// jsonSchemaOf describes the JSON encoding of the values of type t.
+func jsonSchemaOf(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": jsonSchemaOf(t.Elem()),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": jsonSchemaOf(t.Elem()),
+		}
+	case reflect.Struct:
+		var (
+			props    = make(map[string]interface{})
+			required []string
+		)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name, opts := f.Name, ""
+			if tag := f.Tag.Get("json"); tag != "" {
+				if tag == "-" {
+					continue
+				}
+				if i := strings.Index(tag, ","); i >= 0 {
+					tag, opts = tag[:i], tag[i:]
+				}
+				if tag != "" {
+					name = tag
+				}
+			}
+			props[name] = jsonSchemaOf(f.Type)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]interface{}{
+			"type":       "object",
+			"properties": props,
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func writeSchema(w io.Writer, schema map[string]interface{}) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	e.SetEscapeHTML(false)
+	return e.Encode(schema)
+}
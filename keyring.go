@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+var ErrNoKeyring = errors.New("keyring not available")
+
+// KeyringStore is a CredentialStore keeping credentials in the keyring of the
+// operating system: the login keychain on macOS, the secret service (through
+// secret-tool) on the other Unix systems. Name is the name of the keyring
+// entries, usually the name of the application.
+type KeyringStore struct {
+	Name string
+}
+
+func (k KeyringStore) Get(service string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", k.Name, "-a", service, "-w")
+	case "windows", "plan9", "js":
+		return "", ErrNoKeyring
+	default:
+		cmd = exec.Command("secret-tool", "lookup", "service", k.Name, "account", service)
+	}
+	out, err := k.run(cmd, "")
+	if err != nil {
+		return "", err
+	}
+	if out = strings.TrimRight(out, "\r\n"); out == "" {
+		return "", ErrNoCredentials
+	}
+	return out, nil
+}
+
+func (k KeyringStore) Set(service, token string) error {
+	var (
+		cmd   *exec.Cmd
+		input string
+	)
+	switch runtime.GOOS {
+	case "darwin":
+		// the token is given, in hexadecimal, to the interactive mode of
+		// security on its standard input, so that it does not show in the
+		// arguments of the process
+		cmd = exec.Command("security", "-i")
+		input = fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n", securityQuote(k.Name), securityQuote(service), hex.EncodeToString([]byte(token)))
+	case "windows", "plan9", "js":
+		return ErrNoKeyring
+	default:
+		label := fmt.Sprintf("%s: %s", k.Name, service)
+		cmd = exec.Command("secret-tool", "store", "--label", label, "service", k.Name, "account", service)
+		input = token
+	}
+	_, err := k.run(cmd, input)
+	return err
+}
+
+func (k KeyringStore) Delete(service string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "delete-generic-password", "-s", k.Name, "-a", service)
+	case "windows", "plan9", "js":
+		return ErrNoKeyring
+	default:
+		if _, err := k.Get(service); err != nil {
+			return err
+		}
+		cmd = exec.Command("secret-tool", "clear", "service", k.Name, "account", service)
+	}
+	_, err := k.run(cmd, "")
+	return err
+}
+
+// securityQuote quotes str for the interactive mode of the security command.
+func securityQuote(str string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(str) + `"`
+}
+
+func (k KeyringStore) run(cmd *exec.Cmd, input string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err == nil {
+		return stdout.String(), nil
+	}
+	var exit *exec.ExitError
+	if !errors.As(err, &exit) {
+		return "", fmt.Errorf("%w: %s", ErrNoKeyring, err)
+	}
+	// both tools exit with a non zero code when the entry does not exist
+	if msg := strings.TrimSpace(stderr.String()); msg != "" && !strings.Contains(msg, "could not be found") {
+		return "", fmt.Errorf("keyring: %s", msg)
+	}
+	return "", ErrNoCredentials
+}
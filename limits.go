@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+)
+
+var errLimits = errors.New("resource limits not supported on this platform")
+
+var ioClasses = []string{"idle", "best-effort"}
+
+// Limits restrict the resources used by the process running a command, to
+// be polite on shared servers. The zero value of a field leaves the
+// corresponding limit unchanged.
+type Limits struct {
+	// OpenFiles is the maximum number of open files.
+	OpenFiles uint64
+	// CoreSize is the maximum size of the core dumps. NoCore disables them.
+	CoreSize Size
+	NoCore   bool
+	// Nice is the niceness of the process, from -20 to 19. Negative values
+	// usually need privileges.
+	Nice int
+	// IOClass is the I/O scheduling class of the process, idle or
+	// best-effort. It is only supported on Linux.
+	IOClass string
+}
+
+// Flags registers in set the flags controlling l.
+func (l *Limits) Flags(set *flag.FlagSet) {
+	set.Uint64Var(&l.OpenFiles, "max-open-files", l.OpenFiles, "maximum number of open files")
+	set.Var(coreValue{l}, "core-size", "maximum size of core dumps (0 disables them)")
+	set.Var(niceValue{&l.Nice}, "nice", "niceness of the process")
+	set.Var(ioClassValue{&l.IOClass}, "ionice", "I/O scheduling class (idle or best-effort)")
+}
+
+// Apply sets the limits of the current process.
+func (l *Limits) Apply() error {
+	if l.OpenFiles > 0 {
+		if err := setRlimit(rlimitOpenFiles, l.OpenFiles); err != nil {
+			return fmt.Errorf("max open files: %w", err)
+		}
+	}
+	if l.CoreSize > 0 || l.NoCore {
+		n := uint64(l.CoreSize)
+		if l.NoCore {
+			n = 0
+		}
+		if err := setRlimit(rlimitCore, n); err != nil {
+			return fmt.Errorf("core size: %w", err)
+		}
+	}
+	if l.Nice != 0 {
+		if err := setNice(l.Nice); err != nil {
+			return fmt.Errorf("nice: %w", err)
+		}
+	}
+	if l.IOClass != "" {
+		if err := setIOClass(l.IOClass); err != nil {
+			return fmt.Errorf("ionice: %w", err)
+		}
+	}
+	return nil
+}
+
+type coreValue struct {
+	limits *Limits
+}
+
+func (c coreValue) Set(str string) error {
+	var s Size
+	if err := s.Set(str); err != nil {
+		return err
+	}
+	c.limits.CoreSize, c.limits.NoCore = s, s == 0
+	return nil
+}
+
+func (c coreValue) Type() string {
+	return "size"
+}
+
+func (c coreValue) String() string {
+	if c.limits == nil || c.limits.CoreSize == 0 {
+		return ""
+	}
+	return c.limits.CoreSize.String()
+}
+
+type niceValue struct {
+	nice *int
+}
+
+func (n niceValue) Set(str string) error {
+	v, err := strconv.Atoi(str)
+	if err != nil {
+		return fmt.Errorf("%s: invalid niceness", str)
+	}
+	if v < -20 || v > 19 {
+		return fmt.Errorf("%d: niceness out of range (expected -20 to 19)", v)
+	}
+	*n.nice = v
+	return nil
+}
+
+func (n niceValue) Type() string {
+	return "int"
+}
+
+func (n niceValue) String() string {
+	if n.nice == nil {
+		return "0"
+	}
+	return strconv.Itoa(*n.nice)
+}
+
+type ioClassValue struct {
+	class *string
+}
+
+func (i ioClassValue) Set(str string) error {
+	for _, c := range ioClasses {
+		if c == str {
+			*i.class = str
+			return nil
+		}
+	}
+	return choiceError("I/O class", str, ioClasses)
+}
+
+func (i ioClassValue) Type() string {
+	return "class"
+}
+
+func (i ioClassValue) String() string {
+	if i.class == nil {
+		return ""
+	}
+	return *i.class
+}
@@ -0,0 +1,17 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package cli
+
+const (
+	rlimitOpenFiles = iota
+	rlimitCore
+)
+
+func setRlimit(resource int, n uint64) error {
+	return errLimits
+}
+
+func setNice(n int) error {
+	return errLimits
+}
@@ -0,0 +1,30 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package cli
+
+import (
+	"syscall"
+)
+
+const (
+	rlimitOpenFiles = syscall.RLIMIT_NOFILE
+	rlimitCore      = syscall.RLIMIT_CORE
+)
+
+// setRlimit sets the soft limit of the resource, bounded by its hard limit.
+func setRlimit(resource int, n uint64) error {
+	var lim syscall.Rlimit
+	if err := syscall.Getrlimit(resource, &lim); err != nil {
+		return err
+	}
+	lim.Cur = rlimValue(n)
+	if lim.Cur > lim.Max {
+		lim.Cur = lim.Max
+	}
+	return syscall.Setrlimit(resource, &lim)
+}
+
+func setNice(n int) error {
+	return syscall.Setpriority(syscall.PRIO_PROCESS, 0, n)
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+)
+
+// URL is a flag value accepting absolute URLs only.
+type URL struct {
+	*url.URL
+}
+
+func (u *URL) Set(str string) error {
+	v, err := url.Parse(str)
+	if err != nil {
+		return fmt.Errorf("%s: invalid URL", str)
+	}
+	if v.Scheme == "" || v.Host == "" {
+		return fmt.Errorf("%s: invalid URL (scheme and host are required)", str)
+	}
+	u.URL = v
+	return nil
+}
+
+func (u *URL) reset(def string) {
+	u.URL = nil
+	if def != "" {
+		u.Set(def)
+	}
+}
+
+func (u *URL) String() string {
+	if u == nil || u.URL == nil {
+		return ""
+	}
+	return u.URL.String()
+}
+
+// IP is a flag value for an IPv4 or IPv6 address. Like CIDR, it is built on
+// net.IP rather than on net/netip, which needs Go 1.18 while the module
+// supports Go 1.16.
+type IP struct {
+	net.IP
+}
+
+func (i *IP) Set(str string) error {
+	ip := net.ParseIP(str)
+	if ip == nil {
+		return fmt.Errorf("%s: invalid IP address", str)
+	}
+	i.IP = ip
+	return nil
+}
+
+func (i *IP) reset(def string) {
+	i.IP = nil
+	if def != "" {
+		i.Set(def)
+	}
+}
+
+func (i *IP) String() string {
+	if i == nil || i.IP == nil {
+		return ""
+	}
+	return i.IP.String()
+}
+
+// CIDR is a flag value for a network given in CIDR notation. The address as
+// given is kept in IP while IPNet holds the network it belongs to. See IP for
+// why net/netip is not used.
+type CIDR struct {
+	IP net.IP
+	*net.IPNet
+}
+
+func (c *CIDR) Set(str string) error {
+	ip, ipnet, err := net.ParseCIDR(str)
+	if err != nil {
+		return fmt.Errorf("%s: invalid CIDR range", str)
+	}
+	c.IP, c.IPNet = ip, ipnet
+	return nil
+}
+
+func (c *CIDR) reset(def string) {
+	c.IP, c.IPNet = nil, nil
+	if def != "" {
+		c.Set(def)
+	}
+}
+
+func (c *CIDR) String() string {
+	if c == nil || c.IPNet == nil {
+		return ""
+	}
+	ones, _ := c.Mask.Size()
+	return fmt.Sprintf("%s/%d", c.IP, ones)
+}
+
+// HostPort is a flag value for a host:port endpoint. The host can be empty
+// and the port can be given by number or by service name.
+type HostPort struct {
+	Host string
+	Port int
+}
+
+func (h *HostPort) Set(str string) error {
+	host, port, err := net.SplitHostPort(str)
+	if err != nil {
+		return fmt.Errorf("%s: invalid endpoint (expected host:port)", str)
+	}
+	n, err := net.LookupPort("tcp", port)
+	if err != nil || n <= 0 || n > 65535 {
+		return fmt.Errorf("%s: invalid port %q", str, port)
+	}
+	h.Host, h.Port = host, n
+	return nil
+}
+
+func (h *HostPort) reset(def string) {
+	h.Host, h.Port = "", 0
+	if def != "" {
+		h.Set(def)
+	}
+}
+
+func (h *HostPort) String() string {
+	if h == nil || (h.Host == "" && h.Port == 0) {
+		return ""
+	}
+	return h.Addr()
+}
+
+// Addr returns the endpoint in a form accepted by net.Dial.
+func (h HostPort) Addr() string {
+	return net.JoinHostPort(h.Host, strconv.Itoa(h.Port))
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+)
+
+const OfflineExitCode = 69
+
+var ErrOffline = errors.New("network access disabled (offline mode)")
+
+var offline int32
+
+type offlineKey struct{}
+
+// Offline reports whether the command owning ctx runs in offline mode, enabled
+// with the global --offline flag, or whether the whole program does, after
+// SetOffline. In this mode, the clients built with HTTPClient only serve
+// responses from their cache to the requests made with ctx and fail with
+// ErrOffline otherwise.
+func Offline(ctx context.Context) bool {
+	if on, _ := ctx.Value(offlineKey{}).(bool); on {
+		return true
+	}
+	return atomic.LoadInt32(&offline) == 1
+}
+
+func withOffline(ctx context.Context) context.Context {
+	return context.WithValue(ctx, offlineKey{}, true)
+}
+
+// SetOffline enables or disables the offline mode for the whole program.
+func SetOffline(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&offline, v)
+}
+
+type offlineTransport struct {
+	inner http.RoundTripper
+}
+
+func (t offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if Offline(req.Context()) {
+		return nil, ErrOffline
+	}
+	return t.inner.RoundTrip(req)
+}
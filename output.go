@@ -0,0 +1,310 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Formats are the formats supported by NewPrinter.
+var Formats = []string{"text", "json", "yaml", "csv"}
+
+// Printer renders records in a given format. Records are structs (their
+// exported fields named after their json tag), maps with string keys or any
+// other value printed as a single column. Flush has to be called once all the
+// records are printed.
+type Printer interface {
+	Print(record interface{}) error
+	Flush() error
+}
+
+func NewPrinter(w io.Writer, format string) (Printer, error) {
+	switch format {
+	case "text", "":
+		return &textPrinter{w: tabwriter.NewWriter(w, 4, 2, 2, ' ', 0)}, nil
+	case "json":
+		return &jsonPrinter{w: w}, nil
+	case "yaml":
+		return &yamlPrinter{w: w}, nil
+	case "csv":
+		return &csvPrinter{w: csv.NewWriter(w)}, nil
+	default:
+		return nil, choiceError("output format", format, Formats)
+	}
+}
+
+// Printer returns a Printer writing to the standard output of the command in
+// the format selected with its -output flag. When the command is run by
+// Capture, the Printer gives the records to the caller instead. When the user
+// configured a post-exec hook for the command, the records are also given to
+// the hook.
+func (c *Command) Printer() (Printer, error) {
+	if c.parent != nil {
+		if r := recorderFrom(c.parent); r != nil {
+			return r, nil
+		}
+	}
+	p, err := NewPrinter(c.Stdout(), c.Output)
+	if err != nil || c.parent == nil {
+		return p, err
+	}
+	if r := hookRecorderFrom(c.parent); r != nil {
+		p = teePrinter{Printer: p, hook: r}
+	}
+	return p, nil
+}
+
+type outputValue struct {
+	format *string
+}
+
+func (o outputValue) Set(str string) error {
+	for _, f := range Formats {
+		if f == str {
+			*o.format = str
+			return nil
+		}
+	}
+	return choiceError("output format", str, Formats)
+}
+
+func (o outputValue) String() string {
+	if o.format == nil {
+		return ""
+	}
+	return *o.format
+}
+
+func (o outputValue) Type() string {
+	return "format"
+}
+
+type textPrinter struct {
+	w      *tabwriter.Writer
+	header bool
+}
+
+func (p *textPrinter) Print(record interface{}) error {
+	keys, values := recordFields(record)
+	if !p.header && len(keys) > 1 {
+		p.header = true
+		fmt.Fprintln(p.w, strings.ToUpper(strings.Join(keys, "\t")))
+	}
+	_, err := fmt.Fprintln(p.w, strings.Join(formatFields(values), "\t"))
+	return err
+}
+
+func (p *textPrinter) Flush() error {
+	return p.w.Flush()
+}
+
+type jsonPrinter struct {
+	w    io.Writer
+	list []interface{}
+}
+
+func (p *jsonPrinter) Print(record interface{}) error {
+	p.list = append(p.list, record)
+	return nil
+}
+
+func (p *jsonPrinter) Flush() error {
+	if p.list == nil {
+		p.list = []interface{}{}
+	}
+	e := json.NewEncoder(p.w)
+	e.SetIndent("", "  ")
+	e.SetEscapeHTML(false)
+	err := e.Encode(p.list)
+	p.list = nil
+	return err
+}
+
+type yamlPrinter struct {
+	w io.Writer
+}
+
+func (p *yamlPrinter) Print(record interface{}) error {
+	keys, values := recordFields(record)
+	if len(keys) == 1 && keys[0] == "" {
+		_, err := fmt.Fprintf(p.w, "- %s\n", yamlValue(values[0]))
+		return err
+	}
+	for i := range keys {
+		prefix := "  "
+		if i == 0 {
+			prefix = "- "
+		}
+		if _, err := fmt.Fprintf(p.w, "%s%s: %s\n", prefix, yamlString(keys[i]), yamlValue(values[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *yamlPrinter) Flush() error {
+	return nil
+}
+
+type csvPrinter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (p *csvPrinter) Print(record interface{}) error {
+	keys, values := recordFields(record)
+	if !p.header && !(len(keys) == 1 && keys[0] == "") {
+		p.header = true
+		if err := p.w.Write(keys); err != nil {
+			return err
+		}
+	}
+	return p.w.Write(formatFields(values))
+}
+
+func (p *csvPrinter) Flush() error {
+	p.w.Flush()
+	return p.w.Error()
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// recordFields returns the names and the values of the fields of record. A
+// record that is neither a struct nor a map has a single unnamed field.
+func recordFields(record interface{}) ([]string, []interface{}) {
+	v := reflect.ValueOf(record)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch {
+	case v.Kind() == reflect.Struct && v.Type() != timeType:
+		var (
+			keys   []string
+			values []interface{}
+			typ    = v.Type()
+		)
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name := f.Name
+			if tag := f.Tag.Get("json"); tag != "" {
+				if tag = strings.Split(tag, ",")[0]; tag == "-" {
+					continue
+				} else if tag != "" {
+					name = tag
+				}
+			}
+			keys = append(keys, name)
+			values = append(values, v.Field(i).Interface())
+		}
+		return keys, values
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		values := make([]interface{}, len(keys))
+		for i, k := range keys {
+			values[i] = v.MapIndex(reflect.ValueOf(k).Convert(v.Type().Key())).Interface()
+		}
+		return keys, values
+	default:
+		return []string{""}, []interface{}{record}
+	}
+}
+
+func formatFields(values []interface{}) []string {
+	list := make([]string, len(values))
+	for i, v := range values {
+		list[i] = formatField(v)
+	}
+	return list
+}
+
+func formatField(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case fmt.Stringer:
+		return v.String()
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Struct:
+		buf, err := json.Marshal(v)
+		if err == nil {
+			return string(buf)
+		}
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		list := make([]string, rv.Len())
+		for i := range list {
+			list[i] = formatField(rv.Index(i).Interface())
+		}
+		return strings.Join(list, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+var yamlPlain = regexp.MustCompile(`^[A-Za-z_/.][A-Za-z0-9_ /.@-]*$`)
+
+func yamlString(str string) string {
+	switch strings.ToLower(str) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return strconv.Quote(str)
+	}
+	if yamlPlain.MatchString(str) && !strings.HasSuffix(str, " ") {
+		return str
+	}
+	return strconv.Quote(str)
+}
+
+// yamlValue formats v as a YAML scalar. Lists and objects use the flow style
+// of JSON, which is valid YAML.
+func yamlValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return yamlString(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case json.Marshaler:
+		buf, err := json.Marshal(v)
+		if err == nil {
+			return string(buf)
+		}
+	case fmt.Stringer:
+		return yamlString(v.String())
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v)
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return yamlString(fmt.Sprint(v))
+	}
+	return string(buf)
+}
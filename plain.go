@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+type plainKey struct{}
+
+// Plain reports whether the output has to stay plain: no colors, no progress
+// bar and no line redrawn in place. It is the case on dumb terminals, in the
+// shells of Emacs and on continuous integration, where the output ends up in
+// logs, unless the global --plain flag says otherwise.
+func Plain(ctx context.Context) bool {
+	plain, ok := ctx.Value(plainKey{}).(bool)
+	if !ok {
+		return plainTerminal()
+	}
+	return plain
+}
+
+func withPlain(ctx context.Context, plain bool) context.Context {
+	return context.WithValue(ctx, plainKey{}, plain)
+}
+
+// plainTerminal guesses from the environment whether the output can only be
+// plain.
+func plainTerminal() bool {
+	if os.Getenv("TERM") == "dumb" || strings.Contains(os.Getenv("INSIDE_EMACS"), "comint") {
+		return true
+	}
+	switch os.Getenv("CI") {
+	case "", "0", "false":
+		return false
+	default:
+		return true
+	}
+}
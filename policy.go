@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Policy lists the commands and versions of an app that should not run
+// anymore, for instance because of a harmful bug. It is usually published
+// by the organization distributing the app as a JSON document like:
+//
+//	{"rules": [{"versions": ["v1.4.*"], "commands": ["sync"], "message": "data loss, upgrade to v1.4.3"}]}
+type Policy struct {
+	Rules []PolicyRule `json:"rules"`
+}
+
+// PolicyRule disables the commands matching Commands in the versions matching
+// Versions, both in the syntax of filepath.Match. An empty list matches
+// everything.
+type PolicyRule struct {
+	Versions []string `json:"versions,omitempty"`
+	Commands []string `json:"commands,omitempty"`
+	Message  string   `json:"message,omitempty"`
+}
+
+func (r PolicyRule) match(version, cmd string) bool {
+	return matchAny(r.Versions, version) && matchAny(r.Commands, cmd)
+}
+
+func matchAny(list []string, str string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, pat := range list {
+		if ok, _ := filepath.Match(pat, str); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// PolicySource gives the current policy of an application.
+type PolicySource interface {
+	Policy(ctx context.Context) (*Policy, error)
+}
+
+// PolicyURL is a PolicySource reading the policy from URL. URL can also be
+// the path of a local file.
+type PolicyURL struct {
+	URL    string
+	Client *HTTPClient
+}
+
+func (p PolicyURL) Policy(ctx context.Context) (*Policy, error) {
+	var (
+		buf []byte
+		err error
+	)
+	if isURL(p.URL) {
+		buf, err = fetchVersion(ctx, p.Client, p.URL)
+	} else {
+		buf, err = os.ReadFile(ExpandHome(p.URL))
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pol Policy
+	if err := json.Unmarshal(buf, &pol); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.URL, err)
+	}
+	return &pol, nil
+}
+
+type policyState struct {
+	Checked time.Time `json:"checked"`
+	Policy  *Policy   `json:"policy"`
+	// Failed is the time of the last fetch that failed.
+	Failed time.Time `json:"failed,omitempty"`
+}
+
+// due reports whether the policy should be fetched again at now.
+func (s policyState) due(now time.Time) bool {
+	return now.Sub(s.Checked) >= policyInterval && now.Sub(s.Failed) >= policyRetry
+}
+
+const (
+	policyKey      = "policy"
+	policyInterval = time.Hour
+	policyRetry    = 10 * time.Minute
+	policyTimeout  = 3 * time.Second
+)
+
+// checkPolicy returns an error when the policy of the app disables c in the
+// current version. The policy is fetched at most once an hour and kept in the
+// cache. The check never fails by itself: without policy, because the source
+// cannot be reached or the app is offline, every command is allowed. When the
+// source cannot be reached, the last policy known is used and the source is
+// not tried again for ten minutes, so that the runs do not wait for it.
+func (a *App) checkPolicy(ctx context.Context, c *Command) error {
+	if a.Policy == nil {
+		return nil
+	}
+	var (
+		cache = DefaultCache()
+		state policyState
+	)
+	if buf, err := cache.Get(policyKey); err == nil {
+		json.Unmarshal(buf, &state)
+	}
+	clock := ClockFrom(ctx)
+	if state.due(clock.Now()) && !Offline(ctx) {
+		sub, cancel := context.WithTimeout(ctx, policyTimeout)
+		pol, err := a.Policy.Policy(sub)
+		cancel()
+		if err == nil {
+			state = policyState{Checked: clock.Now(), Policy: pol}
+		} else {
+			state.Failed = clock.Now()
+		}
+		buf, _ := json.Marshal(state)
+		cache.Put(policyKey, buf)
+	}
+	if state.Policy == nil {
+		return nil
+	}
+	version := versionInfo(ctx).Version
+	for _, r := range state.Policy.Rules {
+		if !r.match(version, c.String()) {
+			continue
+		}
+		if r.Message == "" {
+			return fmt.Errorf("%s: disabled in version %s", c, version)
+		}
+		return fmt.Errorf("%s: disabled in version %s: %s", c, version, r.Message)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"flag"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// profiles are the files given with the --cpuprofile, --memprofile and
+// --trace flags registered when App.Profiling is set.
+type profiles struct {
+	cpu   string
+	mem   string
+	trace string
+}
+
+func (p *profiles) register(fset *flag.FlagSet) {
+	fset.StringVar(&p.cpu, "cpuprofile", "", "")
+	fset.StringVar(&p.mem, "memprofile", "", "")
+	fset.StringVar(&p.trace, "trace", "", "")
+}
+
+// start starts the collection of the CPU profile and of the trace. The
+// returned function stops them and writes the memory profile, once. It is
+// registered with atExit, by restoreOnce, so that the profiles are also
+// written when the process exits because of an error, a panic or a signal
+// before Run returns.
+func (p *profiles) start() (func(), error) {
+	if p.cpu == "" && p.mem == "" && p.trace == "" {
+		return func() {}, nil
+	}
+	var files []*os.File
+	stop := func() {
+		if p.cpu != "" {
+			pprof.StopCPUProfile()
+		}
+		if p.trace != "" {
+			trace.Stop()
+		}
+		if p.mem != "" {
+			if f, err := os.Create(p.mem); err == nil {
+				runtime.GC()
+				pprof.WriteHeapProfile(f)
+				f.Close()
+			}
+		}
+		for _, f := range files {
+			f.Close()
+		}
+	}
+	if p.cpu != "" {
+		f, err := os.Create(p.cpu)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if p.trace != "" {
+		f, err := os.Create(p.trace)
+		if err == nil {
+			files = append(files, f)
+			err = trace.Start(f)
+		}
+		if err != nil {
+			p.trace = ""
+			stop()
+			return nil, err
+		}
+	}
+	return restoreOnce(stop), nil
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProfilesAtExit(t *testing.T) {
+	var (
+		dir = t.TempDir()
+		p   = profiles{
+			cpu: filepath.Join(dir, "cpu.prof"),
+			mem: filepath.Join(dir, "mem.prof"),
+		}
+	)
+	stop, err := p.start()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	runAtExit()
+	for _, file := range []string{p.cpu, p.mem} {
+		if i, err := os.Stat(file); err != nil || i.Size() == 0 {
+			t.Errorf("%s: profile not written at exit (%v)", file, err)
+		}
+	}
+	if err := os.Remove(p.mem); err != nil {
+		t.Fatal(err)
+	}
+	stop()
+	if _, err := os.Stat(p.mem); err == nil {
+		t.Errorf("the profiles should only be written once")
+	}
+}
@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ProgressMode int
+
+const (
+	ProgressAuto ProgressMode = iota
+	ProgressBar
+	ProgressJSON
+	ProgressNone
+)
+
+func (p ProgressMode) String() string {
+	switch p {
+	case ProgressBar:
+		return "bar"
+	case ProgressJSON:
+		return "json"
+	case ProgressNone:
+		return "none"
+	default:
+		return "auto"
+	}
+}
+
+func (p *ProgressMode) Set(str string) error {
+	switch strings.ToLower(str) {
+	case "auto", "":
+		*p = ProgressAuto
+	case "bar":
+		*p = ProgressBar
+	case "json":
+		*p = ProgressJSON
+	case "none":
+		*p = ProgressNone
+	default:
+		return choiceError("progress mode", str, []string{"auto", "bar", "json", "none"})
+	}
+	return nil
+}
+
+type progressKey struct{}
+
+// ProgressFormat returns the mode selected with the global --progress flag.
+// Progress is disabled in deterministic mode.
+func ProgressFormat(ctx context.Context) ProgressMode {
+	if Deterministic(ctx) {
+		return ProgressNone
+	}
+	mode, _ := ctx.Value(progressKey{}).(ProgressMode)
+	if mode == ProgressAuto {
+		mode = ProgressBar
+	}
+	return mode
+}
+
+func withProgress(ctx context.Context, mode ProgressMode) context.Context {
+	return context.WithValue(ctx, progressKey{}, mode)
+}
+
+type ProgressEvent struct {
+	Time    time.Time `json:"time"`
+	Label   string    `json:"label,omitempty"`
+	Phase   string    `json:"phase,omitempty"`
+	Percent float64   `json:"percent"`
+	Bytes   Size      `json:"bytes"`
+	Total   Size      `json:"total,omitempty"`
+	ETA     float64   `json:"eta,omitempty"`
+	Done    bool      `json:"done,omitempty"`
+}
+
+// Progress reports the progress of an operation either by drawing a bar on a
+// terminal or by emitting one JSON event per line, according to the mode
+// given by ProgressFormat. Progress can be used as an io.Writer counting the
+// bytes written through it.
+type Progress struct {
+	mode  ProgressMode
+	out   io.Writer
+	label string
+	total Size
+	clock Clock
+
+	mu      sync.Mutex
+	phase   string
+	current Size
+	start   time.Time
+	last    time.Time
+}
+
+// NewProgress returns a Progress writing to w. Unless the bar is explicitly
+// asked for, nothing is reported when w is not a terminal or in plain mode.
+func NewProgress(ctx context.Context, w io.Writer, label string, total Size) *Progress {
+	w = chatter(ctx, w)
+	mode := ProgressFormat(ctx)
+	if given, _ := ctx.Value(progressKey{}).(ProgressMode); given == ProgressAuto && (!isOutputTerminal(w) || Plain(ctx)) {
+		mode = ProgressNone
+	}
+	return &Progress{
+		mode:  mode,
+		out:   w,
+		label: label,
+		total: total,
+		clock: ClockFrom(ctx),
+		start: ClockFrom(ctx).Now(),
+	}
+}
+
+func (p *Progress) Write(b []byte) (int, error) {
+	p.Add(Size(len(b)))
+	return len(b), nil
+}
+
+func (p *Progress) Add(n Size) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current += n
+	p.report(false, false)
+}
+
+func (p *Progress) Phase(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.phase = name
+	p.report(true, false)
+}
+
+func (p *Progress) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.report(true, true)
+	if p.mode == ProgressBar {
+		fmt.Fprintln(p.out)
+	}
+}
+
+func (p *Progress) event(done bool) ProgressEvent {
+	now := p.clock.Now()
+	e := ProgressEvent{
+		Time:  now,
+		Label: p.label,
+		Phase: p.phase,
+		Bytes: p.current,
+		Total: p.total,
+		Done:  done,
+	}
+	if p.total > 0 {
+		e.Percent = p.current.Percent(p.total)
+		if p.current > 0 && p.current < p.total {
+			elapsed := now.Sub(p.start).Seconds()
+			e.ETA = elapsed * float64(p.total-p.current) / float64(p.current)
+		}
+	}
+	if done && p.total == 0 {
+		e.Percent = 100
+	}
+	return e
+}
+
+func (p *Progress) report(force, done bool) {
+	if p.mode == ProgressNone {
+		return
+	}
+	now := p.clock.Now()
+	if !force && now.Sub(p.last) < 100*time.Millisecond {
+		return
+	}
+	p.last = now
+
+	e := p.event(done)
+	if p.mode == ProgressJSON {
+		json.NewEncoder(p.out).Encode(e)
+		return
+	}
+	var buf strings.Builder
+	buf.WriteString("\r")
+	if p.label != "" {
+		buf.WriteString(p.label)
+		buf.WriteString(" ")
+	}
+	if p.total > 0 {
+		const width = 30
+		n := int(e.Percent * width / 100)
+		if n > width {
+			n = width
+		}
+		fmt.Fprintf(&buf, "[%s%s] %3.0f%% %s/%s", strings.Repeat("=", n), strings.Repeat(" ", width-n), e.Percent, e.Bytes.Human(IEC), e.Total.Human(IEC))
+		if e.ETA > 0 {
+			fmt.Fprintf(&buf, " eta %s", time.Duration(e.ETA*float64(time.Second)).Round(time.Second))
+		}
+	} else {
+		buf.WriteString(e.Bytes.Human(IEC))
+	}
+	if p.phase != "" {
+		fmt.Fprintf(&buf, " (%s)", p.phase)
+	}
+	buf.WriteString("\033[K")
+	io.WriteString(p.out, buf.String())
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate is a throughput expressed in bytes per second.
+type Rate float64
+
+func NewRate(s Size, d time.Duration) Rate {
+	if d <= 0 {
+		return 0
+	}
+	return Rate(float64(s) / d.Seconds())
+}
+
+// ParseRate parses a rate given either as a size per period (10MB/s, 1GiB/h,
+// 512KiB/100ms) or in bits per second with SI prefixes (2Mbps, 100kbit/s).
+// A rate in bytes per second can also be written with the Bps suffix (5MBps).
+func ParseRate(str string) (Rate, error) {
+	str = strings.TrimSpace(str)
+	if strings.HasSuffix(str, "ps") {
+		return parseRatePerSecond(str)
+	}
+	i := strings.Index(str, "/")
+	if i < 0 {
+		return 0, fmt.Errorf("%s: invalid rate (missing period)", str)
+	}
+	per, err := ratePeriod(str[i+1:])
+	if err != nil {
+		return 0, err
+	}
+	amount := str[:i]
+	if x := strings.LastIndex(amount, "bit"); x >= 0 && strings.TrimSuffix(amount[x:], "s") == "bit" {
+		bits, err := parseBits(amount[:x])
+		if err != nil {
+			return 0, fmt.Errorf("%s: invalid rate", str)
+		}
+		return Rate(bits / 8 / per.Seconds()), nil
+	}
+	size, err := ParseSize(amount)
+	if err != nil {
+		return 0, err
+	}
+	return NewRate(size, per), nil
+}
+
+func parseRatePerSecond(str string) (Rate, error) {
+	var (
+		unit = str[:len(str)-2]
+		bits bool
+	)
+	switch {
+	case strings.HasSuffix(unit, "b"):
+		bits = true
+	case strings.HasSuffix(unit, "B"):
+	default:
+		return 0, fmt.Errorf("%s: invalid rate", str)
+	}
+	unit = unit[:len(unit)-1]
+	n, err := parseBits(unit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid rate", str)
+	}
+	if bits {
+		n /= 8
+	}
+	return Rate(n), nil
+}
+
+func parseBits(str string) (float64, error) {
+	mul := 1.0
+	if n := len(str); n > 0 {
+		switch str[n-1] {
+		case 'k', 'K':
+			mul = 1e3
+		case 'M':
+			mul = 1e6
+		case 'G':
+			mul = 1e9
+		case 'T':
+			mul = 1e12
+		}
+		if mul > 1 {
+			str = str[:n-1]
+		}
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("%s: invalid number", str)
+	}
+	return f * mul, nil
+}
+
+func ratePeriod(str string) (time.Duration, error) {
+	switch str {
+	case "s", "sec":
+		return time.Second, nil
+	case "m", "min":
+		return time.Minute, nil
+	case "h":
+		return time.Hour, nil
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s: invalid period", str)
+	}
+	return d, nil
+}
+
+func (r *Rate) Set(str string) error {
+	v, err := ParseRate(str)
+	if err == nil {
+		*r = v
+	}
+	return err
+}
+
+func (r Rate) String() string {
+	return Size(math.Round(float64(r))).String() + "/s"
+}
+
+// Human formats r in bytes per second with the units of base.
+func (r Rate) Human(base SizeBase) string {
+	return Size(math.Round(float64(r))).Human(base) + "/s"
+}
+
+// Bits formats r in bits per second with SI prefixes.
+func (r Rate) Bits() string {
+	var (
+		value = float64(r) * 8
+		units = []string{"bps", "kbps", "Mbps", "Gbps", "Tbps"}
+		i     int
+	)
+	for i < len(units)-1 && value >= 1000 {
+		value /= 1000
+		i++
+	}
+	str := strconv.FormatFloat(value, 'f', 2, 64)
+	return strings.TrimRight(strings.TrimRight(str, "0"), ".") + units[i]
+}
+
+// Size returns the amount of data transferred at rate r during d.
+func (r Rate) Size(d time.Duration) Size {
+	return Size(math.Round(float64(r) * d.Seconds()))
+}
+
+// Duration returns the time needed to transfer s at rate r.
+func (r Rate) Duration(s Size) time.Duration {
+	if r <= 0 {
+		return 0
+	}
+	return time.Duration(float64(s) / float64(r) * float64(time.Second))
+}
@@ -0,0 +1,74 @@
+package cli
+
+import "fmt"
+
+// Result is what a command set with RunResult returns instead of printing it
+// itself. Its records go through the Printer of the command, so that they are
+// given in the format selected by the user, to the caller of Capture and to
+// the post-exec hooks, and its warnings can be suppressed like the others.
+type Result struct {
+	Records  []interface{}
+	Warnings []ResultWarning
+	// Code, when not zero, is the exit code of the program even when the
+	// command returns no error.
+	Code int
+}
+
+// ResultWarning is a warning of a Result, printed with Warn.
+type ResultWarning struct {
+	ID      string
+	Message string
+}
+
+// Add appends record to the records of r.
+func (r *Result) Add(record interface{}) {
+	r.Records = append(r.Records, record)
+}
+
+// Warn appends the warning id to the warnings of r.
+func (r *Result) Warn(id, format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, ResultWarning{
+		ID:      id,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// run calls the function of c given by Run or RunResult.
+func (c *Command) run(args []string) error {
+	if c.Run != nil {
+		return c.Run(c, args)
+	}
+	res, err := c.RunResult(c, args)
+	if res == nil {
+		return err
+	}
+	if perr := res.print(c); perr != nil && err == nil {
+		err = perr
+	}
+	for _, w := range res.Warnings {
+		c.Warn(w.ID, "%s", w.Message)
+	}
+	if res.Code == 0 {
+		return err
+	}
+	if err == nil {
+		err = fmt.Errorf("%s: exit status %d", c, res.Code)
+	}
+	return Exit(err, res.Code)
+}
+
+func (r *Result) print(c *Command) error {
+	if len(r.Records) == 0 {
+		return nil
+	}
+	p, err := c.Printer()
+	if err != nil {
+		return err
+	}
+	for _, rec := range r.Records {
+		if err := p.Print(rec); err != nil {
+			return err
+		}
+	}
+	return p.Flush()
+}
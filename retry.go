@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"math/rand"
+	"time"
+)
+
+// RetryOptions configures Retry. The delay before the n-th retry is Delay
+// multiplied n-1 times by Factor, bounded by MaxDelay, and shifted by a random
+// amount of up to Jitter times itself.
+type RetryOptions struct {
+	// Retries is the number of attempts made after the first one fails.
+	Retries  int
+	Delay    time.Duration
+	MaxDelay time.Duration
+	// Factor defaults to 2.
+	Factor float64
+	Jitter float64
+	// Retryable, when set, reports whether an error is worth retrying. Errors
+	// marked with Permanent, ErrOffline and the errors of a cancelled context
+	// are never retried.
+	Retryable func(error) bool
+	// Notify, when set, is called before waiting for the next attempt.
+	Notify func(attempt int, err error, wait time.Duration)
+}
+
+// RetryFlags registers -retries and -retry-delay on set and returns the
+// options they configure.
+func RetryFlags(set *flag.FlagSet) *RetryOptions {
+	opts := RetryOptions{
+		Retries:  3,
+		Delay:    time.Second,
+		MaxDelay: time.Minute,
+		Jitter:   0.2,
+	}
+	set.IntVar(&opts.Retries, "retries", opts.Retries, "number of retries of failed requests")
+	set.DurationVar(&opts.Delay, "retry-delay", opts.Delay, "delay before the first retry")
+	return &opts
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks err as not worth retrying.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// Retry calls fn until it succeeds, returns a permanent error or runs out of
+// retries. It returns the last error of fn, or the error of ctx if it is
+// cancelled while waiting. In deterministic mode, delays have no jitter.
+func Retry(ctx context.Context, opts RetryOptions, fn func(context.Context) error) error {
+	if opts.Factor <= 0 {
+		opts.Factor = 2
+	}
+	delay := opts.Delay
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt > opts.Retries || !opts.retryable(err) {
+			return err
+		}
+		wait := delay
+		if opts.Jitter > 0 && !Deterministic(ctx) {
+			wait += time.Duration((rand.Float64()*2 - 1) * opts.Jitter * float64(wait))
+		}
+		if opts.Notify != nil {
+			opts.Notify(attempt, err, wait)
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+		delay = time.Duration(float64(delay) * opts.Factor)
+		if opts.MaxDelay > 0 && delay > opts.MaxDelay {
+			delay = opts.MaxDelay
+		}
+	}
+}
+
+func (o RetryOptions) retryable(err error) bool {
+	var perm permanentError
+	if errors.As(err, &perm) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrOffline) {
+		return false
+	}
+	if o.Retryable != nil {
+		return o.Retryable(err)
+	}
+	return true
+}
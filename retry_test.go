@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// instantClock is a Clock whose timers fire at once, recording their
+// durations.
+type instantClock struct {
+	waits []time.Duration
+}
+
+func (c *instantClock) Now() time.Time {
+	return time.Unix(0, 0)
+}
+
+func (c *instantClock) After(d time.Duration) <-chan time.Time {
+	c.waits = append(c.waits, d)
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+func TestRetryBackoff(t *testing.T) {
+	errFail := errors.New("fail")
+	tests := []struct {
+		name  string
+		opts  RetryOptions
+		fails int
+		err   error
+		calls int
+		waits []time.Duration
+	}{
+		{
+			name:  "success",
+			opts:  RetryOptions{Retries: 3, Delay: time.Second},
+			calls: 1,
+		},
+		{
+			name:  "exponential",
+			opts:  RetryOptions{Retries: 5, Delay: time.Second},
+			fails: 4,
+			calls: 5,
+			waits: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+		{
+			name:  "bounded",
+			opts:  RetryOptions{Retries: 4, Delay: time.Second, Factor: 3, MaxDelay: 5 * time.Second},
+			fails: 10,
+			err:   errFail,
+			calls: 5,
+			waits: []time.Duration{time.Second, 3 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+		{
+			name:  "no retries",
+			opts:  RetryOptions{Delay: time.Second},
+			fails: 1,
+			err:   errFail,
+			calls: 1,
+		},
+		{
+			name:  "not retryable",
+			opts:  RetryOptions{Retries: 3, Delay: time.Second, Retryable: func(error) bool { return false }},
+			fails: 3,
+			err:   errFail,
+			calls: 1,
+		},
+	}
+	for _, tt := range tests {
+		var (
+			clock = instantClock{}
+			ctx   = withClock(context.Background(), &clock)
+			calls int
+		)
+		err := Retry(ctx, tt.opts, func(context.Context) error {
+			calls++
+			if calls <= tt.fails {
+				return errFail
+			}
+			return nil
+		})
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, tt.err)
+		}
+		if calls != tt.calls {
+			t.Errorf("%s: got %d calls, want %d", tt.name, calls, tt.calls)
+		}
+		if !reflect.DeepEqual(clock.waits, tt.waits) {
+			t.Errorf("%s: got waits %v, want %v", tt.name, clock.waits, tt.waits)
+		}
+	}
+}
+
+func TestRetryJitter(t *testing.T) {
+	var (
+		clock = instantClock{}
+		ctx   = withClock(context.Background(), &clock)
+		opts  = RetryOptions{Retries: 20, Delay: time.Second, Factor: 1, Jitter: 0.5}
+	)
+	Retry(ctx, opts, func(context.Context) error {
+		return errors.New("fail")
+	})
+	for _, w := range clock.waits {
+		if w < 500*time.Millisecond || w > 1500*time.Millisecond {
+			t.Errorf("wait %s out of the jitter bounds", w)
+		}
+	}
+	clock.waits = nil
+	Retry(withDeterministic(ctx), opts, func(context.Context) error {
+		return errors.New("fail")
+	})
+	for _, w := range clock.waits {
+		if w != time.Second {
+			t.Errorf("wait %s has jitter in deterministic mode", w)
+		}
+	}
+}
+
+func TestRetryPermanent(t *testing.T) {
+	errFail := errors.New("fail")
+	for _, err := range []error{Permanent(errFail), ErrOffline, context.Canceled} {
+		var calls int
+		got := Retry(withClock(context.Background(), &instantClock{}), RetryOptions{Retries: 3}, func(context.Context) error {
+			calls++
+			return err
+		})
+		if calls != 1 || !errors.Is(got, err) {
+			t.Errorf("%v: got %d calls and error %v, want a single call", err, calls, got)
+		}
+	}
+}
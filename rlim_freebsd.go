@@ -0,0 +1,5 @@
+package cli
+
+func rlimValue(n uint64) int64 {
+	return int64(n)
+}
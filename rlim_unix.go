@@ -0,0 +1,8 @@
+//go:build linux || darwin || netbsd || openbsd
+// +build linux darwin netbsd openbsd
+
+package cli
+
+func rlimValue(n uint64) uint64 {
+	return n
+}
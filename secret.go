@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var ErrNoSecret = errors.New("no secret given")
+
+const redacted = "********"
+
+// Secret is a flag value for passwords, tokens and other credentials. Its
+// value never appears in the help, the logs or the output of String.
+//
+// A value starting with @ is the path of a file holding the secret, like
+// @/run/secrets/token, so that the secret does not appear in the list of the
+// processes. When the flag is not given, Value reads the secret from the
+// environment variable Env, if set, or asks for it on the terminal.
+type Secret struct {
+	Env    string
+	Prompt string
+
+	value string
+}
+
+func (s *Secret) Set(str string) error {
+	if strings.HasPrefix(str, "@") {
+		buf, err := os.ReadFile(ExpandHome(str[1:]))
+		if err != nil {
+			return err
+		}
+		str = strings.TrimRight(string(buf), "\r\n")
+	}
+	s.value = str
+	return nil
+}
+
+func (s *Secret) String() string {
+	if s == nil || s.value == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s *Secret) Type() string {
+	return "secret"
+}
+
+// Value returns the secret given with the flag, from the environment or typed
+// by the user on the terminal, in this order.
+func (s *Secret) Value() (string, error) {
+	if s.value != "" {
+		return s.value, nil
+	}
+	if s.Env != "" {
+		if str := os.Getenv(s.Env); str != "" {
+			s.value = str
+			return str, nil
+		}
+	}
+	prompt := s.Prompt
+	if prompt == "" {
+		prompt = "password: "
+	}
+	str, err := ReadSecret(prompt)
+	if err != nil {
+		return "", err
+	}
+	if str == "" {
+		return "", ErrNoSecret
+	}
+	s.value = str
+	return str, nil
+}
+
+// ReadSecret prints prompt on the standard error and reads a line from the
+// standard input without echoing it.
+func ReadSecret(prompt string) (string, error) {
+	return readSecret(context.Background(), os.Stdin, os.Stderr, prompt)
+}
+
+// readSecret prints prompt on w and reads a line from r, without echo when r
+// is a terminal. In plain mode, the terminal is left as it is, the shells of
+// Emacs hiding the secrets typed by themselves.
+func readSecret(ctx context.Context, r io.Reader, w io.Writer, prompt string) (string, error) {
+	var restore func()
+	if f, ok := r.(*os.File); ok && !Plain(ctx) {
+		restore, _ = DisableEcho(f)
+	}
+	fmt.Fprint(w, prompt)
+	str, err := readLine(r)
+	if restore != nil {
+		restore()
+		fmt.Fprintln(w)
+	}
+	return str, err
+}
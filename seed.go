@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"sync"
+)
+
+type seedKey struct{}
+
+type seedState struct {
+	seed int64
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// Rand returns the pseudo-random generator of the current run of c, always
+// the same during the run. It is seeded with the value of the global --seed
+// flag, with 0 in deterministic mode and with a random seed otherwise. When
+// the command fails after using it, the seed is printed so that the run can
+// be reproduced. The generator is not safe for concurrent use.
+func Rand(c *Command) *rand.Rand {
+	s, ok := c.Context().Value(seedKey{}).(*seedState)
+	if !ok {
+		return rand.New(rand.NewSource(ClockFrom(c.Context()).Now().UnixNano()))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rand == nil {
+		s.rand = rand.New(rand.NewSource(s.seed))
+	}
+	return s.rand
+}
+
+// usedSeed returns the seed of the generator returned by Rand, if it was
+// called.
+func usedSeed(ctx context.Context) (int64, bool) {
+	s, ok := ctx.Value(seedKey{}).(*seedState)
+	if !ok {
+		return 0, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rand == nil {
+		return 0, false
+	}
+	return s.seed, true
+}
+
+func withSeed(ctx context.Context, seed int64) context.Context {
+	return context.WithValue(ctx, seedKey{}, &seedState{seed: seed})
+}
+
+type seedValue struct {
+	seed int64
+	set  bool
+}
+
+func (s *seedValue) Set(str string) error {
+	n, err := strconv.ParseInt(str, 0, 64)
+	if err != nil {
+		return err
+	}
+	s.seed, s.set = n, true
+	return nil
+}
+
+func (s *seedValue) String() string {
+	if s == nil || !s.set {
+		return ""
+	}
+	return strconv.FormatInt(s.seed, 10)
+}
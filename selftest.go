@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const selftestCmd = "selftest"
+
+// selftest runs the examples of the commands that give their expected output
+// and reports the ones printing something else, for a quick check of an
+// installed program. Only the examples of the commands given in names are run
+// when names is not empty.
+//
+// The examples run in deterministic mode, without the configuration loaded by
+// the app, from an empty temporary directory that is also the home and the
+// configuration directory of the program, with the flags of the commands reset
+// to their defaults.
+func (a *App) selftest(ctx context.Context, w io.Writer, names []string) error {
+	dir, err := os.MkdirTemp("", programName()+"-selftest-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	ctx = withConfig(withDeterministic(ctx), nil)
+	var total, failed int
+	for _, c := range a.Commands {
+		if !c.Runnable() || (len(names) > 0 && !containsString(names, c.String())) {
+			continue
+		}
+		for _, e := range c.Examples {
+			if e.Output == "" {
+				continue
+			}
+			total++
+			ok, err := a.runSandboxed(ctx, w, filepath.Join(dir, strconv.Itoa(total)), e)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				failed++
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d examples failed", failed, total)
+	}
+	fmt.Fprintf(w, "%d examples passed\n", total)
+	return nil
+}
+
+// runSandboxed runs e in the sandbox dir, see sandbox, and gives the process
+// its environment and working directory back.
+func (a *App) runSandboxed(ctx context.Context, w io.Writer, dir string, e Example) (bool, error) {
+	restore, err := sandbox(dir)
+	if err != nil {
+		return false, err
+	}
+	defer restore()
+	return a.runExample(ctx, w, e), nil
+}
+
+// runExample runs e and reports whether it printed the expected output.
+func (a *App) runExample(ctx context.Context, w io.Writer, e Example) bool {
+	var (
+		stdout bytes.Buffer
+		stderr bytes.Buffer
+	)
+	err := a.runExampleTo(ctx, e, &stdout, &stderr)
+	if err == nil && sameOutput(e.Output, stdout.String()) {
+		fmt.Fprintf(w, "ok    %s\n", e.Cmd)
+		return true
+	}
+	fmt.Fprintf(w, "FAIL  %s\n", e.Cmd)
+	if err != nil {
+		fmt.Fprintf(w, "  error: %s\n", err)
+	}
+	if stderr.Len() > 0 {
+		fmt.Fprintf(w, "  stderr: %s\n", strings.TrimSpace(stderr.String()))
+	}
+	if !sameOutput(e.Output, stdout.String()) {
+		Diff(ctx, w, "expected", "actual", []byte(e.Output), stdout.Bytes())
+	}
+	return false
+}
+
+func (a *App) runExampleTo(ctx context.Context, e Example, stdout, stderr io.Writer) error {
+	args, err := Split(e.Cmd)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 && (args[0] == a.name() || args[0] == programName()) {
+		args = args[1:]
+	}
+	if args, err = a.expand(ctx, args); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("no command")
+	}
+	c := a.lookup(args[0])
+	if c == nil {
+		return a.suggest(args[0])
+	}
+	return a.executeTo(ctx, c, args[1:], stdout, stderr)
+}
+
+// sandbox creates dir and makes it the working, home and configuration
+// directory of the process. The function returned restores them.
+func sandbox(dir string) (func(), error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	restore := SaveEnv()
+	for _, v := range []string{"HOME", "USERPROFILE", "APPDATA", "LOCALAPPDATA", "XDG_CONFIG_HOME", "XDG_CACHE_HOME", "XDG_STATE_HOME", "XDG_DATA_HOME"} {
+		os.Setenv(v, dir)
+	}
+	if err := os.Chdir(dir); err != nil {
+		restore()
+		return nil, err
+	}
+	return func() {
+		os.Chdir(wd)
+		restore()
+	}, nil
+}
+
+// sameOutput compares the output of an example ignoring the spaces at the end
+// of the lines and of the output.
+func sameOutput(want, got string) bool {
+	return trimLines(want) == trimLines(got)
+}
+
+func trimLines(str string) string {
+	lines := strings.Split(strings.TrimRight(str, " \t\r\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t\r")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrQuote = errors.New("unterminated quoted string")
+
+// Shell reads lines from stdin and runs them as commands of app until the end
+// of input or until one of the exit or quit commands is given.
+//
+// Besides the commands of the app, the shell recognizes the following
+// builtins: help [command], history, !! and !n to rerun the last or the nth
+// entry of the history.
+func Shell(app *App) error {
+	interactive := app.Interactive
+	app.Interactive = true
+	defer func() {
+		app.Interactive = interactive
+	}()
+
+	var (
+		scan    = bufio.NewScanner(app.stdin())
+		stdout  = app.stdout()
+		stderr  = app.stderr()
+		history []string
+	)
+	for {
+		fmt.Fprint(stdout, app.prompt())
+		if !scan.Scan() {
+			fmt.Fprintln(stdout)
+			break
+		}
+		line := strings.TrimSpace(scan.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "!") {
+			prev, err := recall(history, line[1:])
+			if err != nil {
+				fmt.Fprintln(stderr, err)
+				continue
+			}
+			line = prev
+			fmt.Fprintln(stdout, line)
+		}
+		history = append(history, line)
+
+		args, err := Split(line)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			continue
+		}
+		switch args[0] {
+		case "exit", "quit":
+			return nil
+		case "history":
+			for i, h := range history {
+				fmt.Fprintf(stdout, "%4d  %s\n", i+1, h)
+			}
+		case "help":
+			app.help(stdout, args[1:])
+		default:
+			err = app.Run(args)
+		}
+		if err != nil && !errors.Is(err, flag.ErrHelp) {
+			report(stderr, err, app.Commands, app.ExitPolicy)
+		}
+	}
+	return scan.Err()
+}
+
+func (a *App) prompt() string {
+	if a.Prompt != "" {
+		return a.Prompt
+	}
+	return a.name() + "> "
+}
+
+func recall(history []string, which string) (string, error) {
+	if len(history) == 0 {
+		return "", fmt.Errorf("history is empty")
+	}
+	if which == "!" {
+		return history[len(history)-1], nil
+	}
+	n, err := strconv.Atoi(which)
+	if err != nil || n < 1 || n > len(history) {
+		return "", fmt.Errorf("!%s: event not found", which)
+	}
+	return history[n-1], nil
+}
+
+// Split splits line into words the way a shell does. Words are separated by
+// blanks and can be quoted with single or double quotes. Outside quotes, a
+// backslash escapes the character following it. Inside double quotes, it only
+// escapes ", \, $ and `.
+func Split(line string) ([]string, error) {
+	var (
+		words []string
+		buf   strings.Builder
+		quote rune
+		word  bool
+		esc   bool
+	)
+	for _, r := range line {
+		switch {
+		case esc && r == '\n':
+			esc = false
+		case esc:
+			if quote == '"' && !strings.ContainsRune("\"\\$`", r) {
+				buf.WriteRune('\\')
+			}
+			buf.WriteRune(r)
+			esc = false
+		case r == '\\' && quote != '\'':
+			esc, word = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				buf.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, word = r, true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if word {
+				words = append(words, buf.String())
+				buf.Reset()
+				word = false
+			}
+		default:
+			buf.WriteRune(r)
+			word = true
+		}
+	}
+	if quote != 0 || esc {
+		return nil, ErrQuote
+	}
+	if word {
+		words = append(words, buf.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	words := map[string][]string{
+		"":                       nil,
+		"  list  -a\tfoo ":       {"list", "-a", "foo"},
+		`echo "hello world"`:     {"echo", "hello world"},
+		`echo 'it''s'`:           {"echo", "its"},
+		`echo "it's" 'say "hi"'`: {"echo", "it's", `say "hi"`},
+		`echo a\ b`:              {"echo", "a b"},
+		`echo '\n' "\""`:         {"echo", `\n`, `"`},
+		`echo "" ''`:             {"echo", "", ""},
+		`cp "a b"/c 'd'"e"f`:     {"cp", "a b/c", "def"},
+	}
+	for line, want := range words {
+		got, err := Split(line)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: got %q, want %q", line, got, want)
+		}
+	}
+	for _, line := range []string{`echo "unterminated`, `echo 'unterminated`, `echo "a' b`} {
+		if _, err := Split(line); !errors.Is(err, ErrQuote) {
+			t.Errorf("%q: got %v, want ErrQuote", line, err)
+		}
+	}
+}
+
+func TestShell(t *testing.T) {
+	var (
+		name  string
+		count int
+		cmd   = Command{
+			Usage: "greet [-name name] [-n count]",
+			Short: "greet someone",
+		}
+	)
+	cmd.Flag.StringVar(&name, "name", "world", "")
+	cmd.Flag.IntVar(&count, "n", 1, "")
+	cmd.Run = func(c *Command, args []string) error {
+		if err := c.Parse(args); err != nil {
+			return err
+		}
+		fmt.Fprintln(c.Stdout(), strings.Repeat("hello "+name+" ", count))
+		return nil
+	}
+	var (
+		input = "greet -name bob -n 2\ngreet\ngreet -h\nhelp\n!1\nhistory\nexit\ngreet\n"
+		out   bytes.Buffer
+		app   = App{
+			Commands: []*Command{&cmd},
+			Stdin:    strings.NewReader(input),
+			Stdout:   &out,
+			Stderr:   &out,
+			Prompt:   "> ",
+		}
+	)
+	if err := Shell(&app); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"> hello bob hello bob \n",
+		"> hello world \n",
+		"greet someone",
+		"> greet -name bob -n 2\nhello bob hello bob \n",
+		"   6  history\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output does not have %q:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "hello world") != 1 {
+		t.Errorf("the shell should stop at exit:\n%s", got)
+	}
+	if app.Interactive {
+		t.Errorf("the shell should restore the Interactive field of the app")
+	}
+}
+
+func TestShellResetFlags(t *testing.T) {
+	var (
+		mode = Enum{Values: []string{"fast", "slow"}}
+		dir  Dir
+		cmd  = Command{
+			Usage: "show [-mode mode] [-dir dir]",
+			Short: "show the flags",
+		}
+	)
+	cmd.Flag.Var(&mode, "mode", "")
+	cmd.Flag.Var(&dir, "dir", "")
+	cmd.Run = func(c *Command, args []string) error {
+		if err := c.Flag.Parse(args); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.Stdout(), "mode=%q dir=%q\n", mode.Value, dir.Path)
+		return nil
+	}
+	var (
+		tmp   = t.TempDir()
+		input = fmt.Sprintf("show -mode fast -dir %s\nshow\n", tmp)
+		out   bytes.Buffer
+		app   = App{
+			Commands: []*Command{&cmd},
+			Stdin:    strings.NewReader(input),
+			Stdout:   &out,
+			Stderr:   &out,
+		}
+	)
+	if err := Shell(&app); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		fmt.Sprintf("mode=\"fast\" dir=%q\n", tmp),
+		"mode=\"\" dir=\"\"\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output does not have %q:\n%s", want, got)
+		}
+	}
+}
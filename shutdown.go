@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type ShutdownPhase struct {
+	Name    string
+	Timeout time.Duration
+	Run     func(context.Context) error
+}
+
+// Shutdown runs ordered phases to stop a server like command, each one with
+// its own timeout. A typical server stops accepting new requests, drains the
+// ones in progress and finally forces what remains to stop:
+//
+//	var sd cli.Shutdown
+//	sd.Add("stop", time.Second, func(ctx context.Context) error { return ln.Close() })
+//	sd.Add("drain", 30*time.Second, srv.Shutdown)
+//	sd.Add("force", 5*time.Second, func(ctx context.Context) error { return srv.Close() })
+//
+//	ctx, stop := sd.Watch(c.Context())
+//	defer stop()
+//	<-ctx.Done()
+//	return sd.Run(context.Background())
+//
+// A phase that fails or exceeds its timeout does not prevent the next ones to
+// run.
+type Shutdown struct {
+	Phases []ShutdownPhase
+	Logger *log.Logger
+
+	mu      sync.Mutex
+	running bool
+}
+
+func (s *Shutdown) Add(name string, timeout time.Duration, fn func(context.Context) error) {
+	s.Phases = append(s.Phases, ShutdownPhase{
+		Name:    name,
+		Timeout: timeout,
+		Run:     fn,
+	})
+}
+
+// Watch returns a context cancelled when ctx is or when the process receives
+// an interrupt or a termination signal. Until the returned function is
+// called, the first signal no longer exits the process as it does by default.
+// A second one still exits it immediately.
+func (s *Shutdown) Watch(ctx context.Context) (context.Context, func()) {
+	return watchSignals(ctx)
+}
+
+func watchSignals(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+
+	hooks.Lock()
+	hooks.graceful++
+	hooks.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		var n int
+		for {
+			select {
+			case v := <-sig:
+				if n++; n == 1 {
+					cancel()
+					continue
+				}
+				exit(signalCode(v))
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			signal.Stop(sig)
+			close(done)
+			cancel()
+
+			hooks.Lock()
+			hooks.graceful--
+			hooks.Unlock()
+		})
+	}
+}
+
+// Run runs the phases in order and returns the errors of the phases that
+// failed.
+func (s *Shutdown) Run(ctx context.Context) error {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return fmt.Errorf("shutdown already in progress")
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	var errs Errors
+	for _, p := range s.Phases {
+		if err := s.run(ctx, p); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+		}
+	}
+	return errs.Err()
+}
+
+func (s *Shutdown) run(ctx context.Context, p ShutdownPhase) error {
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+	var (
+		logger = s.logger()
+		start  = ClockFrom(ctx).Now()
+		errc   = make(chan error, 1)
+	)
+	logger.Printf("%s: starting", p.Name)
+	go func() {
+		errc <- p.Run(ctx)
+	}()
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	elapsed := since(ctx, start).Round(time.Millisecond)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		logger.Printf("%s: timed out after %s", p.Name, elapsed)
+	case err != nil:
+		logger.Printf("%s: failed after %s: %s", p.Name, elapsed, err)
+	default:
+		logger.Printf("%s: done in %s", p.Name, elapsed)
+	}
+	return err
+}
+
+func (s *Shutdown) logger() *log.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return log.New(os.Stderr, "shutdown: ", log.LstdFlags)
+}
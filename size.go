@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type Size int64
+
+const (
+	Byte Size = 1
+
+	KB Size = 1000
+	MB      = KB * 1000
+	GB      = MB * 1000
+	TB      = GB * 1000
+	PB      = TB * 1000
+	EB      = PB * 1000
+
+	KiB Size = 1 << 10
+	MiB      = KiB << 10
+	GiB      = MiB << 10
+	TiB      = GiB << 10
+	PiB      = TiB << 10
+	EiB      = PiB << 10
+)
+
+type SizeBase int
+
+const (
+	IEC SizeBase = iota
+	SI
+)
+
+var (
+	siUnits  = []string{"B", "KB", "MB", "GB", "TB", "PB", "EB"}
+	iecUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+)
+
+// ParseSize parses a size made of a number followed by an optional unit.
+// SI units (KB, MB, ...) are powers of 1000, IEC units (KiB, MiB, ...) and
+// their single letter forms (K, M, ...) are powers of 1024. Units are case
+// insensitive. The number can be negative, like the sizes printed by String.
+func ParseSize(str string) (Size, error) {
+	str = strings.TrimSpace(str)
+	var sign string
+	if strings.HasPrefix(str, "-") {
+		sign = "-"
+	}
+	rest := str[len(sign):]
+	i := strings.IndexFunc(rest, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(rest)
+	}
+	num, unit := rest[:i], strings.TrimSpace(rest[i:])
+	if num == "" {
+		return 0, fmt.Errorf("%s: invalid size", str)
+	}
+	mul, err := sizeUnit(unit)
+	if err != nil {
+		return 0, err
+	}
+	if !strings.Contains(num, ".") {
+		n, err := strconv.ParseInt(sign+num, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s: invalid size", str)
+		}
+		if n > math.MaxInt64/int64(mul) || n < math.MinInt64/int64(mul) {
+			return 0, fmt.Errorf("%s: size out of range", str)
+		}
+		return Size(n) * mul, nil
+	}
+	f, err := strconv.ParseFloat(sign+num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid size", str)
+	}
+	f *= float64(mul)
+	if f >= math.MaxInt64 || f < math.MinInt64 {
+		return 0, fmt.Errorf("%s: size out of range", str)
+	}
+	return Size(math.Round(f)), nil
+}
+
+func sizeUnit(unit string) (Size, error) {
+	switch strings.ToLower(unit) {
+	case "", "b":
+		return Byte, nil
+	case "k", "kib":
+		return KiB, nil
+	case "m", "mib":
+		return MiB, nil
+	case "g", "gib":
+		return GiB, nil
+	case "t", "tib":
+		return TiB, nil
+	case "p", "pib":
+		return PiB, nil
+	case "e", "eib":
+		return EiB, nil
+	case "kb":
+		return KB, nil
+	case "mb":
+		return MB, nil
+	case "gb":
+		return GB, nil
+	case "tb":
+		return TB, nil
+	case "pb":
+		return PB, nil
+	case "eb":
+		return EB, nil
+	default:
+		return 0, fmt.Errorf("%s: unknown size unit", unit)
+	}
+}
+
+func (s *Size) Set(str string) error {
+	v, err := ParseSize(str)
+	if err == nil {
+		*s = v
+	}
+	return err
+}
+
+// String formats s exactly so that the result can be parsed back by
+// ParseSize. The largest unit giving a value with at most two decimals is
+// used.
+func (s Size) String() string {
+	units := []Size{EiB, EB, PiB, PB, TiB, TB, GiB, GB, MiB, MB, KiB, KB}
+	for _, u := range units {
+		if s < u && -s < u {
+			continue
+		}
+		if r := s % u; r%(u/gcd(u, 100)) != 0 {
+			continue
+		}
+		str := strconv.FormatFloat(float64(s)/float64(u), 'f', -1, 64)
+		return str + u.unit()
+	}
+	return strconv.FormatInt(int64(s), 10) + "B"
+}
+
+func (s Size) unit() string {
+	for i, u := range []Size{KiB, MiB, GiB, TiB, PiB, EiB} {
+		if s == u {
+			return iecUnits[i+1]
+		}
+	}
+	for i, u := range []Size{KB, MB, GB, TB, PB, EB} {
+		if s == u {
+			return siUnits[i+1]
+		}
+	}
+	return siUnits[0]
+}
+
+func gcd(a, b Size) Size {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// Human formats s with the largest unit of base that keeps the value above 1
+// and with at most two decimals.
+func (s Size) Human(base SizeBase) string {
+	var (
+		step  = float64(KiB)
+		units = iecUnits
+		value = float64(s)
+	)
+	if base == SI {
+		step, units = float64(KB), siUnits
+	}
+	var i int
+	for i < len(units)-1 && math.Abs(math.Round(value*100)/100) >= step {
+		value /= step
+		i++
+	}
+	str := strconv.FormatFloat(value, 'f', 2, 64)
+	str = strings.TrimRight(strings.TrimRight(str, "0"), ".")
+	return str + units[i]
+}
+
+func (s Size) Int() int64 {
+	return int64(s)
+}
+
+func (s Size) Add(other Size) Size {
+	return s + other
+}
+
+func (s Size) Sub(other Size) Size {
+	return s - other
+}
+
+func (s Size) Compare(other Size) int {
+	switch {
+	case s < other:
+		return -1
+	case s > other:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (s Size) Less(other Size) bool {
+	return s < other
+}
+
+// Percent gives the ratio of s to total as a percentage.
+func (s Size) Percent(total Size) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(s) * 100 / float64(total)
+}
+
+func (s Size) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Size) UnmarshalText(b []byte) error {
+	return s.Set(string(b))
+}
+
+// MarshalJSON encodes s as a number of bytes. UnmarshalJSON accepts both a
+// number of bytes and a string with a unit.
+func (s Size) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(s), 10), nil
+}
+
+func (s *Size) UnmarshalJSON(b []byte) error {
+	if str, err := strconv.Unquote(string(b)); err == nil {
+		return s.Set(str)
+	}
+	n, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%s: invalid size", b)
+	}
+	*s = Size(n)
+	return nil
+}
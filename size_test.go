@@ -0,0 +1,97 @@
+package cli
+
+import "testing"
+
+func TestSizeSet(t *testing.T) {
+	tests := []struct {
+		str  string
+		want Size
+		err  bool
+	}{
+		{str: "0", want: 0},
+		{str: "512", want: 512},
+		{str: "512B", want: 512},
+		{str: "1k", want: KiB},
+		{str: "1KiB", want: KiB},
+		{str: "1KB", want: KB},
+		{str: "1.5 MiB", want: MiB + MiB/2},
+		{str: "2gb", want: 2 * GB},
+		{str: " 3T ", want: 3 * TiB},
+		{str: "0.5", want: 1},
+		{str: "-1.5k", want: -KiB - KiB/2},
+		{str: "-", err: true},
+		{str: "--1", err: true},
+		{str: "", err: true},
+		{str: "MiB", err: true},
+		{str: "1 XB", err: true},
+		{str: "1.2.3", err: true},
+		{str: "9999999EiB", err: true},
+	}
+	for _, tt := range tests {
+		var s Size
+		err := s.Set(tt.str)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%q: expected error, got %d", tt.str, s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tt.str, err)
+			continue
+		}
+		if s != tt.want {
+			t.Errorf("%q: got %d, want %d", tt.str, s, tt.want)
+		}
+	}
+}
+
+func TestSizeString(t *testing.T) {
+	formats := map[Size]string{
+		0:             "0B",
+		999:           "999B",
+		KB:            "1KB",
+		KiB:           "1KiB",
+		1536:          "1.5KiB",
+		1500:          "1.5KB",
+		1001:          "1001B",
+		3 * GiB:       "3GiB",
+		2*MB + 250*KB: "2.25MB",
+		EiB + EiB/4:   "1.25EiB",
+	}
+	for size, want := range formats {
+		if got := size.String(); got != want {
+			t.Errorf("%d: got %s, want %s", size, got, want)
+		}
+	}
+	// every size is written in a form read back exactly.
+	for _, unit := range []Size{Byte, KB, KiB, MB, MiB, GB, GiB} {
+		for n := Size(-2000); n < 2000; n += 7 {
+			size := n * unit
+			back, err := ParseSize(size.String())
+			if err != nil || back != size {
+				t.Errorf("%d: %s parsed back to %d (%v)", size, size, back, err)
+			}
+		}
+	}
+}
+
+func TestSizeHuman(t *testing.T) {
+	tests := []struct {
+		size Size
+		base SizeBase
+		want string
+	}{
+		{size: 512, base: IEC, want: "512B"},
+		{size: 1536, base: IEC, want: "1.5KiB"},
+		{size: 1536, base: SI, want: "1.54KB"},
+		{size: 1023 * KiB, base: IEC, want: "1023KiB"},
+		{size: 1024*MiB - 1, base: IEC, want: "1GiB"},
+		{size: 999_999, base: SI, want: "1MB"},
+	}
+	for _, tt := range tests {
+		if got := tt.size.Human(tt.base); got != tt.want {
+			t.Errorf("%d (base %d): got %s, want %s", tt.size, tt.base, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package cli
+
+// NewStandardApp returns an app set up like most programs are, to which the
+// commands of the program are added:
+//
+//	app := cli.NewStandardApp("prog", "1.2.0")
+//	app.Commands = append(app.Commands, list, show)
+//	os.Exit(app.Execute(os.Args[1:]))
+//
+// Besides the builtin help, version and completion commands and the global
+// flags for colors, progress, output, warnings and verbosity, the app has:
+//
+//   - the name and version given, for the help, the errors and the version
+//     command, that --version replaces so that it accepts its flags
+//   - the configuration files of name, edited with the config command, and
+//     with the aliases of the users
+//   - the flags of the commands read from the environment, in the variables
+//     prefixed by name, see EnvPrefix
+//   - the docs command writing the reference of the commands
+//   - the cancellation of the running command on the first interrupt
+//   - a panic at the first run when the commands are badly defined
+//
+// Each of them can be turned off by changing the app before it runs, like
+// setting Config to an empty string and removing the config command from
+// Commands for a program without configuration.
+func NewStandardApp(name, version string) *App {
+	return &App{
+		Commands:  []*Command{ConfigCommand(), DocsCommand()},
+		Config:    name,
+		EnvPrefix: name,
+		Info: VersionInfo{
+			Name:    name,
+			Version: version,
+		},
+		Version:   VersionPolicy{Combine: true},
+		Misuse:    MisusePanic,
+		Interrupt: true,
+	}
+}
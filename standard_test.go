@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEnvPrefix(t *testing.T) {
+	var (
+		name  string
+		count int
+		cmd   = Command{Usage: "greet [-user-name name] [-n count]"}
+	)
+	cmd.Flag.StringVar(&name, "user-name", "world", "")
+	cmd.Flag.IntVar(&count, "n", 1, "")
+	cmd.Run = func(c *Command, args []string) error {
+		if err := c.Parse(args); err != nil {
+			return err
+		}
+		c.Verbose(1, "greeting %s", name)
+		c.Verbose(2, "greeting %d times", count)
+		return nil
+	}
+	defer setenv(t, "MY_PROG_USER_NAME", "bob")()
+	defer setenv(t, "MY_PROG_N", "3")()
+
+	var (
+		out bytes.Buffer
+		app = App{Main: &cmd, EnvPrefix: "my-prog", Stdout: &out, Stderr: &out}
+	)
+	if err := app.Run([]string{"--verbose", "-n", "2"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if name != "bob" || count != 2 {
+		t.Errorf("got name %q and count %d, want bob and 2", name, count)
+	}
+	if got, want := out.String(), "i greeting bob\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	defer setenv(t, "MY_PROG_N", "three")()
+	if err := app.Run(nil); err == nil {
+		t.Errorf("expected error for an invalid value in the environment")
+	}
+}
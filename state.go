@@ -0,0 +1,410 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	stateConfig      = "config/"
+	stateCache       = "cache/"
+	stateCredentials = "credentials"
+	stateConfigKey   = "config-key"
+)
+
+// StateCommand returns a builtin command moving the state of the app to
+// another machine:
+//
+//	state export [-cache] [-credentials] <file>
+//	state import [-f] <file>
+//
+// The bundle is a gzipped tar archive of the configuration directory of the
+// user, with the profiles and the other files the app keeps there. With
+// -cache, it also has the metadata of the cache (the files at its top, like
+// the history) but not the cached entries. With -credentials, it has the
+// credentials of DefaultStore, encrypted with a passphrase asked on the
+// terminal. When the configuration has encrypted values, the key decrypting
+// them is exported too, encrypted with the passphrase, and imported in the
+// keyring of the app. Import refuses to overwrite existing files unless -f is
+// given.
+func StateCommand() *Command {
+	var (
+		cache bool
+		creds bool
+		force bool
+		cmd   = Command{
+			Usage: "state <export|import> [-cache] [-credentials] [-f] <file>",
+			Short: "export and import the state of the application",
+		}
+	)
+	cmd.Flag.BoolVar(&cache, "cache", false, "export the metadata of the cache")
+	cmd.Flag.BoolVar(&creds, "credentials", false, "export the credentials")
+	cmd.Flag.BoolVar(&force, "f", false, "overwrite existing files")
+	cmd.Run = func(c *Command, args []string) error {
+		if err := c.Parse(args); err != nil {
+			return err
+		}
+		action := c.Flag.Arg(0)
+		if c.Flag.NArg() > 0 {
+			if err := c.Flag.Parse(c.Flag.Args()[1:]); err != nil {
+				return err
+			}
+		}
+		a := appFrom(c.Context())
+		if a == nil || a.Config == "" {
+			return fmt.Errorf("state: application without configuration")
+		}
+		file := c.Flag.Arg(0)
+		if file == "" {
+			return fmt.Errorf("state %s: missing file", action)
+		}
+		dirs := stateDirs{
+			config:  filepath.Dir(userConfigFile(a.Config)),
+			cache:   DefaultCache().Dir,
+			creds:   DefaultStore().Path,
+			keyring: a.keyring(),
+		}
+		switch action {
+		case "export":
+			var (
+				pass string
+				key  []byte
+			)
+			if cfg := ConfigFrom(c.Context()); cfg != nil && len(cfg.sealed) > 0 {
+				k, err := configSecret(dirs.keyring, false)
+				if err != nil {
+					return fmt.Errorf("state: can not export the configuration key: %w", err)
+				}
+				key = k
+			}
+			if creds || key != nil {
+				p, err := readPassphrase(c, true)
+				if err != nil {
+					return err
+				}
+				pass = p
+			}
+			return Do(c.Context(), "write "+file, func() error {
+				return exportState(c.Context(), file, dirs, cache, creds, pass, key)
+			})
+		case "import":
+			var pass string
+			ask := func() (string, error) {
+				if pass != "" {
+					return pass, nil
+				}
+				p, err := readPassphrase(c, false)
+				pass = p
+				return p, err
+			}
+			return importState(c.Context(), file, dirs, force, ask)
+		default:
+			return fmt.Errorf("%s: unknown action", action)
+		}
+	}
+	return &cmd
+}
+
+type stateDirs struct {
+	config string
+	cache  string
+	creds  string
+	// keyring keeps the key of the encrypted values of the configuration.
+	keyring CredentialStore
+}
+
+func readPassphrase(c *Command, confirm bool) (string, error) {
+	pass, err := readSecret(c.Context(), c.Stdin(), c.Stderr(), "passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if pass == "" {
+		return "", ErrNoSecret
+	}
+	if confirm {
+		again, err := readSecret(c.Context(), c.Stdin(), c.Stderr(), "confirm passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != pass {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+	return pass, nil
+}
+
+func exportState(ctx context.Context, file string, dirs stateDirs, cache, creds bool, pass string, key []byte) error {
+	w, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	var (
+		z  = gzip.NewWriter(w)
+		tw = tar.NewWriter(z)
+	)
+	err = addStateDir(tw, stateConfig, dirs.config, func(rel string, i fs.FileInfo) bool {
+		return filepath.Join(dirs.config, rel) != dirs.creds && !strings.HasSuffix(rel, "~")
+	})
+	if err == nil && cache {
+		err = addStateDir(tw, stateCache, dirs.cache, func(rel string, i fs.FileInfo) bool {
+			return !strings.Contains(rel, "/") && i.Mode().IsRegular()
+		})
+	}
+	if err == nil && key != nil {
+		var buf []byte
+		if buf, err = encryptState(key, pass); err == nil {
+			err = addStateFile(tw, stateConfigKey, buf, Now(ctx))
+		}
+	}
+	if err == nil && creds {
+		var buf []byte
+		if buf, err = os.ReadFile(dirs.creds); err == nil {
+			if buf, err = encryptState(buf, pass); err == nil {
+				err = addStateFile(tw, stateCredentials, buf, Now(ctx))
+			}
+		} else if errors.Is(err, fs.ErrNotExist) {
+			err = ErrNoCredentials
+		}
+	}
+	for _, c := range []io.Closer{tw, z, w} {
+		if e := c.Close(); err == nil {
+			err = e
+		}
+	}
+	if err != nil {
+		os.Remove(file)
+	}
+	return err
+}
+
+func addStateDir(tw *tar.Writer, prefix, dir string, keep func(string, fs.FileInfo) bool) error {
+	err := filepath.Walk(dir, func(file string, i fs.FileInfo, err error) error {
+		if err != nil || !i.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !keep(rel, i) {
+			return nil
+		}
+		buf, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		return addStateFile(tw, prefix+rel, buf, i.ModTime())
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+	return err
+}
+
+func addStateFile(tw *tar.Writer, name string, buf []byte, mod time.Time) error {
+	hdr := tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(buf)),
+		ModTime: mod,
+	}
+	if err := tw.WriteHeader(&hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(buf)
+	return err
+}
+
+// importState checks the whole bundle before writing any file, so that an
+// import failing because of a conflict or a wrong passphrase changes nothing.
+func importState(ctx context.Context, file string, dirs stateDirs, force bool, ask func() (string, error)) error {
+	r, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	z, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	var (
+		tr    = tar.NewReader(z)
+		files = make(map[string][]byte)
+		list  []string
+		key   []byte
+	)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if hdr.Typeflag != tar.TypeReg || !fs.ValidPath(hdr.Name) {
+			continue
+		}
+		var target string
+		switch name := hdr.Name; {
+		case strings.HasPrefix(name, stateConfig):
+			target = filepath.Join(dirs.config, filepath.FromSlash(name[len(stateConfig):]))
+		case strings.HasPrefix(name, stateCache):
+			target = filepath.Join(dirs.cache, filepath.FromSlash(name[len(stateCache):]))
+		case name == stateCredentials:
+			target = dirs.creds
+		case name == stateConfigKey:
+			buf, err := io.ReadAll(tr)
+			if err != nil {
+				return err
+			}
+			if key, err = importConfigKey(dirs.keyring, buf, force, ask); err != nil {
+				return err
+			}
+			continue
+		default:
+			continue
+		}
+		if _, err := os.Stat(target); err == nil && !force {
+			return fmt.Errorf("%s: file already exists", target)
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		buf, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		if hdr.Name == stateCredentials {
+			pass, err := ask()
+			if err != nil {
+				return err
+			}
+			if buf, err = decryptState(buf, pass); err != nil {
+				return err
+			}
+		}
+		files[target] = buf
+		list = append(list, target)
+	}
+	for _, target := range list {
+		err := Do(ctx, "write "+target, func() error {
+			if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+				return err
+			}
+			if buf := files[target]; target != dirs.creds && isText(buf) {
+				return WriteFile(target, buf, 0600, EOL(ctx))
+			}
+			return WriteFileAtomic(target, files[target], 0600)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if key == nil {
+		return nil
+	}
+	return Do(ctx, "store the configuration key", func() error {
+		return dirs.keyring.Set(configKeyName, base64.StdEncoding.EncodeToString(key))
+	})
+}
+
+// importConfigKey decrypts the key of the configuration found in a bundle. It
+// refuses to replace another key already in store unless force is set, since
+// the values encrypted with it could no longer be read.
+func importConfigKey(store CredentialStore, buf []byte, force bool, ask func() (string, error)) ([]byte, error) {
+	pass, err := ask()
+	if err != nil {
+		return nil, err
+	}
+	key, err := decryptState(buf, pass)
+	if err != nil {
+		return nil, err
+	}
+	old, err := configSecret(store, false)
+	switch {
+	case err == nil && !bytes.Equal(old, key) && !force:
+		return nil, fmt.Errorf("%s: another key is already in the keyring", configKeyName)
+	case err == nil && bytes.Equal(old, key):
+		return nil, nil
+	case err != nil && !errors.Is(err, ErrNoCredentials):
+		return nil, err
+	}
+	return key, nil
+}
+
+const (
+	stateSaltSize   = 16
+	stateIterations = 200000
+)
+
+func encryptState(buf []byte, pass string) ([]byte, error) {
+	salt := make([]byte, stateSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(pbkdf2([]byte(pass), salt, stateIterations))
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	out := append(salt, nonce...)
+	return gcm.Seal(out, nonce, buf, nil), nil
+}
+
+func decryptState(buf []byte, pass string) ([]byte, error) {
+	if len(buf) < stateSaltSize {
+		return nil, fmt.Errorf("credentials: invalid bundle")
+	}
+	salt, buf := buf[:stateSaltSize], buf[stateSaltSize:]
+	gcm, err := newGCM(pbkdf2([]byte(pass), salt, stateIterations))
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) < gcm.NonceSize() {
+		return nil, fmt.Errorf("credentials: invalid bundle")
+	}
+	buf, err = gcm.Open(nil, buf[:gcm.NonceSize()], buf[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("credentials: wrong passphrase")
+	}
+	return buf, nil
+}
+
+// pbkdf2 derives a 32 bytes key from pass with PBKDF2-HMAC-SHA256.
+func pbkdf2(pass, salt []byte, iter int) []byte {
+	var (
+		mac = hmac.New(sha256.New, pass)
+		key = make([]byte, sha256.Size)
+	)
+	mac.Write(salt)
+	mac.Write([]byte{0, 0, 0, 1})
+	u := mac.Sum(nil)
+	copy(key, u)
+	for i := 1; i < iter; i++ {
+		mac.Reset()
+		mac.Write(u)
+		u = mac.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return key
+}
@@ -0,0 +1,26 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !windows
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!windows
+
+package cli
+
+import (
+	"errors"
+	"os"
+)
+
+func isTerminal(f *os.File) bool {
+	i, err := f.Stat()
+	return err == nil && i.Mode()&os.ModeCharDevice != 0
+}
+
+func termSize(f *os.File) (int, int, error) {
+	return 0, 0, errors.New("terminal size not supported on this platform")
+}
+
+func setTermMode(f *os.File, raw bool) (func(), error) {
+	return nil, errors.New("terminal mode not supported on this platform")
+}
+
+func enableEscapes(f *os.File) bool {
+	return true
+}
@@ -0,0 +1,69 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package cli
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func setTermMode(f *os.File, raw bool) (func(), error) {
+	var (
+		fd    = f.Fd()
+		state syscall.Termios
+	)
+	if err := ioctlTermios(fd, ioctlGetTermios, &state); err != nil {
+		return nil, err
+	}
+	mode := state
+	if raw {
+		mode.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+		mode.Oflag &^= syscall.OPOST
+		mode.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+		mode.Cflag &^= syscall.CSIZE | syscall.PARENB
+		mode.Cflag |= syscall.CS8
+		mode.Cc[syscall.VMIN] = 1
+		mode.Cc[syscall.VTIME] = 0
+	} else {
+		mode.Lflag &^= syscall.ECHO
+		mode.Lflag |= syscall.ICANON | syscall.ISIG
+		mode.Iflag |= syscall.ICRNL
+	}
+	if err := ioctlTermios(fd, ioctlSetTermios, &mode); err != nil {
+		return nil, err
+	}
+	restore := func() {
+		ioctlTermios(fd, ioctlSetTermios, &state)
+	}
+	return restore, nil
+}
+
+func isTerminal(f *os.File) bool {
+	var state syscall.Termios
+	return ioctlTermios(f.Fd(), ioctlGetTermios, &state) == nil
+}
+
+func termSize(f *os.File) (int, int, error) {
+	var ws struct {
+		Row, Col, Xpixel, Ypixel uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0, 0, errno
+	}
+	return int(ws.Col), int(ws.Row), nil
+}
+
+func ioctlTermios(fd, req uintptr, t *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(unsafe.Pointer(t)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func enableEscapes(f *os.File) bool {
+	return true
+}
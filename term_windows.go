@@ -0,0 +1,104 @@
+//go:build windows
+// +build windows
+
+package cli
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                       = syscall.NewLazyDLL("kernel32.dll")
+	procSetConsoleMode             = kernel32.NewProc("SetConsoleMode")
+	procGetConsoleScreenBufferInfo = kernel32.NewProc("GetConsoleScreenBufferInfo")
+)
+
+const (
+	enableProcessedInput            = 0x0001
+	enableLineInput                 = 0x0002
+	enableEchoInput                 = 0x0004
+	enableVirtualTerminalProcessing = 0x0004
+	enableVirtualTerminalInput      = 0x0200
+)
+
+type coord struct {
+	X, Y int16
+}
+
+type smallRect struct {
+	Left, Top, Right, Bottom int16
+}
+
+type consoleScreenBufferInfo struct {
+	Size              coord
+	CursorPosition    coord
+	Attributes        uint16
+	Window            smallRect
+	MaximumWindowSize coord
+}
+
+func isTerminal(f *os.File) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(f.Fd()), &mode) == nil
+}
+
+func termSize(f *os.File) (int, int, error) {
+	var info consoleScreenBufferInfo
+	r, _, err := procGetConsoleScreenBufferInfo.Call(f.Fd(), uintptr(unsafe.Pointer(&info)))
+	if r == 0 {
+		return 0, 0, err
+	}
+	cols := int(info.Window.Right-info.Window.Left) + 1
+	rows := int(info.Window.Bottom-info.Window.Top) + 1
+	return cols, rows, nil
+}
+
+func setTermMode(f *os.File, raw bool) (func(), error) {
+	var (
+		h    = syscall.Handle(f.Fd())
+		prev uint32
+	)
+	if err := syscall.GetConsoleMode(h, &prev); err != nil {
+		return nil, err
+	}
+	mode := prev &^ enableEchoInput
+	if raw {
+		mode &^= enableLineInput | enableProcessedInput
+		mode |= enableVirtualTerminalInput
+	} else {
+		mode |= enableLineInput | enableProcessedInput
+	}
+	if err := setConsoleMode(h, mode); err != nil {
+		return nil, err
+	}
+	restore := func() {
+		setConsoleMode(h, prev)
+	}
+	return restore, nil
+}
+
+// enableEscapes turns on the processing of the escape sequences written to
+// the console f, needed for colors and cursor movements.
+func enableEscapes(f *os.File) bool {
+	var (
+		h    = syscall.Handle(f.Fd())
+		mode uint32
+	)
+	if err := syscall.GetConsoleMode(h, &mode); err != nil {
+		return false
+	}
+	if mode&enableVirtualTerminalProcessing != 0 {
+		return true
+	}
+	return setConsoleMode(h, mode|enableVirtualTerminalProcessing) == nil
+}
+
+func setConsoleMode(h syscall.Handle, mode uint32) error {
+	r, _, err := procSetConsoleMode.Call(uintptr(h), uintptr(mode))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// HideCursor hides the cursor of the terminal w is connected to. The returned
+// function shows it again. The cursor is also restored if the process exits
+// because of an error, a panic or a signal before it is called.
+func HideCursor(w io.Writer) func() {
+	fmt.Fprint(w, "\033[?25l")
+	return restoreOnce(func() {
+		fmt.Fprint(w, "\033[?25h")
+	})
+}
+
+// DisableEcho turns off the echo of the characters typed on the terminal f
+// until the returned function is called. Like HideCursor, the terminal is
+// restored whatever the way the process exits.
+func DisableEcho(f *os.File) (func(), error) {
+	restore, err := setTermMode(f, false)
+	if err != nil {
+		return nil, err
+	}
+	return restoreOnce(restore), nil
+}
+
+// MakeRaw puts the terminal f in raw mode until the returned function is
+// called. Like HideCursor, the terminal is restored whatever the way the
+// process exits.
+func MakeRaw(f *os.File) (func(), error) {
+	restore, err := setTermMode(f, true)
+	if err != nil {
+		return nil, err
+	}
+	return restoreOnce(restore), nil
+}
+
+// IsTerminal reports whether f is connected to a terminal.
+func IsTerminal(f *os.File) bool {
+	return f != nil && isTerminal(f)
+}
+
+// IsPiped reports whether f is a pipe, like the standard input of a command
+// at the end of a pipeline.
+func IsPiped(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	i, err := f.Stat()
+	return err == nil && i.Mode()&os.ModeNamedPipe != 0
+}
+
+// TerminalSize returns the number of columns and rows of the terminal the
+// standard output is connected to. When it can not be known, the COLUMNS
+// and LINES environment variables are used.
+func TerminalSize() (int, int, error) {
+	cols, rows, err := termSize(os.Stdout)
+	if err == nil && cols > 0 {
+		return cols, rows, nil
+	}
+	c, err1 := strconv.Atoi(os.Getenv("COLUMNS"))
+	r, err2 := strconv.Atoi(os.Getenv("LINES"))
+	if err1 == nil && err2 == nil && c > 0 {
+		return c, r, nil
+	}
+	if err == nil {
+		err = errors.New("terminal size unknown")
+	}
+	return 0, 0, err
+}
+
+// isOutputTerminal reports whether w is a terminal interpreting the escape
+// sequences. On Windows, their processing is enabled on the console.
+func isOutputTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && IsTerminal(f) && enableEscapes(f)
+}
+
+func restoreOnce(fn func()) func() {
+	var (
+		once   sync.Once
+		remove func()
+	)
+	restore := func() {
+		once.Do(fn)
+	}
+	remove = atExit(restore)
+	return func() {
+		remove()
+		restore()
+	}
+}
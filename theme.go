@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type Severity int
+
+const (
+	Info Severity = iota
+	Success
+	Warning
+	Failure
+)
+
+// Style is how a message of a given severity is shown. Symbol is always
+// printed so that severities are not distinguished by color alone.
+type Style struct {
+	Symbol string
+	Color  string
+	Bold   bool
+}
+
+type Theme struct {
+	Name   string
+	Styles map[Severity]Style
+}
+
+var (
+	DefaultTheme = Theme{
+		Name: "default",
+		Styles: map[Severity]Style{
+			Info:    {Symbol: "i", Color: "36"},
+			Success: {Symbol: "✓", Color: "32"},
+			Warning: {Symbol: "!", Color: "33", Bold: true},
+			Failure: {Symbol: "✗", Color: "31", Bold: true},
+		},
+	}
+	// ColorblindTheme uses blue and orange instead of green and red.
+	ColorblindTheme = Theme{
+		Name: "colorblind",
+		Styles: map[Severity]Style{
+			Info:    {Symbol: "i", Color: "37"},
+			Success: {Symbol: "✓", Color: "34"},
+			Warning: {Symbol: "!", Color: "38;5;220", Bold: true},
+			Failure: {Symbol: "✗", Color: "38;5;208", Bold: true},
+		},
+	}
+	PlainTheme = Theme{
+		Name: "plain",
+		Styles: map[Severity]Style{
+			Info:    {Symbol: "i"},
+			Success: {Symbol: "✓"},
+			Warning: {Symbol: "!"},
+			Failure: {Symbol: "✗"},
+		},
+	}
+)
+
+var themes = []Theme{DefaultTheme, ColorblindTheme, PlainTheme}
+
+type themeValue struct {
+	theme *Theme
+}
+
+func (t themeValue) Set(str string) error {
+	var names []string
+	for _, th := range themes {
+		if th.Name == str {
+			*t.theme = th
+			return nil
+		}
+		names = append(names, th.Name)
+	}
+	return choiceError("theme", str, names)
+}
+
+func (t themeValue) String() string {
+	if t.theme == nil {
+		return ""
+	}
+	return t.theme.Name
+}
+
+// Format returns msg prefixed by the symbol of sev and, when color is set,
+// styled according to sev.
+func (t Theme) Format(sev Severity, msg string, color bool) string {
+	style, ok := t.Styles[sev]
+	if !ok {
+		return msg
+	}
+	if style.Symbol != "" {
+		msg = style.Symbol + " " + msg
+	}
+	if !color || (style.Color == "" && !style.Bold) {
+		return msg
+	}
+	var codes []string
+	if style.Bold {
+		codes = append(codes, "1")
+	}
+	if style.Color != "" {
+		codes = append(codes, style.Color)
+	}
+	return "\033[" + strings.Join(codes, ";") + "m" + msg + "\033[0m"
+}
+
+type themeKey struct{}
+
+// ThemeFrom returns the theme selected with the global --theme flag.
+func ThemeFrom(ctx context.Context) Theme {
+	if t, ok := ctx.Value(themeKey{}).(Theme); ok && t.Styles != nil {
+		return t
+	}
+	return DefaultTheme
+}
+
+func withTheme(ctx context.Context, t Theme) context.Context {
+	return context.WithValue(ctx, themeKey{}, t)
+}
+
+// Message prints a message of the given severity on w with the theme of
+// ctx. Colors are only used when w is a terminal, NO_COLOR is not set and the
+// command does not run in deterministic nor in plain mode.
+func Message(ctx context.Context, w io.Writer, sev Severity, format string, args ...interface{}) {
+	w = chatter(ctx, w)
+	fmt.Fprintln(w, ThemeFrom(ctx).Format(sev, fmt.Sprintf(format, args...), useColor(ctx, w)))
+}
+
+func useColor(ctx context.Context, w io.Writer) bool {
+	return isOutputTerminal(w) && os.Getenv("NO_COLOR") == "" && !Deterministic(ctx) && !Plain(ctx)
+}
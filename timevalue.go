@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Time is a flag value for a point in time. See ParseTime for the accepted
+// formats. Relative times are resolved with the clock of the app running the
+// command that parses the flag, see Now.
+type Time struct {
+	time.Time
+
+	ctx context.Context
+}
+
+func (t *Time) Set(str string) error {
+	ctx := t.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	v, err := ParseTime(str, Now(ctx))
+	if err == nil {
+		t.Time = v
+	}
+	return err
+}
+
+func (t *Time) reset(def string) {
+	t.Time = time.Time{}
+	if def != "" {
+		t.Set(def)
+	}
+}
+
+func (t *Time) String() string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// ParseTime parses str as an absolute time (RFC3339 or a date with an
+// optional time in local time), as one of the keywords now, today, yesterday
+// and tomorrow, or as a duration relative to now (-24h, +90m, -3d, -2w).
+func ParseTime(str string, now time.Time) (time.Time, error) {
+	str = strings.TrimSpace(str)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch strings.ToLower(str) {
+	case "now":
+		return now, nil
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+	if strings.HasPrefix(str, "-") || strings.HasPrefix(str, "+") {
+		return relativeTime(str, now)
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, str, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%s: invalid time", str)
+}
+
+func relativeTime(str string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(str); err == nil {
+		return now.Add(d), nil
+	}
+	var days int
+	switch str[len(str)-1] {
+	case 'd':
+		days = 1
+	case 'w':
+		days = 7
+	default:
+		return time.Time{}, fmt.Errorf("%s: invalid relative time", str)
+	}
+	n, err := strconv.Atoi(str[:len(str)-1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: invalid relative time", str)
+	}
+	return now.AddDate(0, 0, n*days), nil
+}
@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestTimeClock(t *testing.T) {
+	var (
+		since Time
+		cmd   = Command{Usage: "log [-since time]"}
+	)
+	cmd.Flag.Var(&since, "since", "")
+	cmd.Run = func(c *Command, args []string) error {
+		return c.Parse(args)
+	}
+	var (
+		out bytes.Buffer
+		app = App{Main: &cmd, Stdout: &out, Stderr: &out, Clock: &instantClock{}}
+	)
+	if err := app.Run([]string{"-since", "-1h"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want := time.Unix(0, 0).Add(-time.Hour); !since.Equal(want) {
+		t.Errorf("got %s, want %s", since.Time, want)
+	}
+}
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NoUpdateEnv is the environment variable disabling the update check when it
+// is set.
+const NoUpdateEnv = "NO_UPDATE_NOTIFIER"
+
+const updateInterval = 24 * time.Hour
+
+// VersionSource gives the latest released version of an application.
+type VersionSource interface {
+	Latest(ctx context.Context) (string, error)
+}
+
+// VersionURL is a VersionSource reading the version from URL, either as plain
+// text or as a JSON object with a version field.
+type VersionURL struct {
+	URL    string
+	Client *HTTPClient
+}
+
+func (v VersionURL) Latest(ctx context.Context) (string, error) {
+	buf, err := fetchVersion(ctx, v.Client, v.URL)
+	if err != nil {
+		return "", err
+	}
+	var doc struct {
+		Version string `json:"version"`
+	}
+	if json.Unmarshal(buf, &doc) == nil && doc.Version != "" {
+		return doc.Version, nil
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
+
+// GitHubRelease is a VersionSource giving the tag of the latest release of a
+// GitHub repository, named like owner/name.
+type GitHubRelease struct {
+	Repo   string
+	Client *HTTPClient
+}
+
+func (g GitHubRelease) Latest(ctx context.Context) (string, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", g.Repo)
+	buf, err := fetchVersion(ctx, g.Client, url)
+	if err != nil {
+		return "", err
+	}
+	var doc struct {
+		Tag string `json:"tag_name"`
+	}
+	if err := json.Unmarshal(buf, &doc); err != nil {
+		return "", err
+	}
+	return doc.Tag, nil
+}
+
+func fetchVersion(ctx context.Context, client *HTTPClient, url string) ([]byte, error) {
+	if client == nil {
+		client = &HTTPClient{Timeout: 10 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, res.Status)
+	}
+	return io.ReadAll(io.LimitReader(res.Body, 1<<16))
+}
+
+type updateState struct {
+	Checked time.Time `json:"checked"`
+	Latest  string    `json:"latest"`
+	// Failures counts the searches failed in a row since the last success.
+	Failures int `json:"failures,omitempty"`
+}
+
+// next returns when the next search can start: a day after the last one,
+// doubled after each failed search, up to a week.
+func (s updateState) next() time.Time {
+	wait := updateInterval
+	for i := 0; i < s.Failures && wait < 7*updateInterval; i++ {
+		wait *= 2
+	}
+	if wait > 7*updateInterval {
+		wait = 7 * updateInterval
+	}
+	return s.Checked.Add(wait)
+}
+
+const updateKey = "update-check"
+
+// checkUpdate starts, at most once a day, the search of the latest version of
+// the app in the background. The returned function prints a notice on the
+// standard error if a newer version is known at that time. It never waits for
+// the search, whose result is kept in the cache for the next runs. The time of
+// the search is recorded before it starts, so that runs shorter than the
+// search do not start it again, and the searches failing are tried less often.
+func (a *App) checkUpdate(ctx context.Context) func() {
+	current := versionInfo(ctx).Version
+	if a.Updates == nil || os.Getenv(NoUpdateEnv) != "" || Offline(ctx) || Deterministic(ctx) {
+		return func() {}
+	}
+	if current == "unknown" || !isOutputTerminal(a.stderr()) {
+		return func() {}
+	}
+	if a.nested() {
+		return func() {}
+	}
+	var (
+		cache = DefaultCache()
+		state updateState
+		done  = make(chan string, 1)
+	)
+	if buf, err := cache.Get(updateKey); err == nil {
+		json.Unmarshal(buf, &state)
+	}
+	clock := ClockFrom(ctx)
+	if now := clock.Now(); !now.Before(state.next()) {
+		state.Checked = now
+		buf, _ := json.Marshal(state)
+		cache.Put(updateKey, buf)
+		go func(state updateState) {
+			latest, err := a.Updates.Latest(context.Background())
+			if err != nil {
+				state.Failures++
+			} else {
+				state.Latest, state.Failures = latest, 0
+			}
+			buf, _ := json.Marshal(state)
+			cache.Put(updateKey, buf)
+			if err == nil {
+				done <- latest
+			}
+		}(state)
+	}
+	return func() {
+		latest := state.Latest
+		select {
+		case latest = <-done:
+		default:
+		}
+		if latest != "" && compareVersions(latest, current) > 0 {
+			Message(ctx, a.stderr(), Info, "a new version of %s is available: %s (current: %s)", a.name(), latest, current)
+		}
+	}
+}
+
+// compareVersions compares versions like v1.2.3, ignoring the prerelease and
+// build suffixes.
+func compareVersions(a, b string) int {
+	split := func(v string) []string {
+		v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+		if i := strings.IndexAny(v, "-+"); i >= 0 {
+			v = v[:i]
+		}
+		return strings.Split(v, ".")
+	}
+	x, y := split(a), split(b)
+	for i := 0; i < len(x) || i < len(y); i++ {
+		var m, n int
+		if i < len(x) {
+			m, _ = strconv.Atoi(x[i])
+		}
+		if i < len(y) {
+			n, _ = strconv.Atoi(y[i])
+		}
+		if m != n {
+			if m < n {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
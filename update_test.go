@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "v1.2.3", b: "v1.2.3", want: 0},
+		{a: "1.2.3", b: "v1.2.3", want: 0},
+		{a: "v1.2.4", b: "v1.2.3", want: 1},
+		{a: "v1.2.3", b: "v1.10.0", want: -1},
+		{a: "v2", b: "v1.9.9", want: 1},
+		{a: "v1.2", b: "v1.2.0", want: 0},
+		{a: "v1.2.0.1", b: "v1.2", want: 1},
+		{a: "v1.3.0-rc1", b: "v1.3.0", want: 0},
+		{a: "v1.3.0+build.5", b: "v1.2.9", want: 1},
+		{a: " v0.1.0\n", b: "v0.0.9", want: 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("%q <> %q: got %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := compareVersions(tt.b, tt.a); got != -tt.want {
+			t.Errorf("%q <> %q: got %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestUpdateBackoff(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 0, want: updateInterval},
+		{failures: 1, want: 2 * updateInterval},
+		{failures: 2, want: 4 * updateInterval},
+		{failures: 3, want: 7 * updateInterval},
+		{failures: 10, want: 7 * updateInterval},
+	}
+	for _, tt := range tests {
+		s := updateState{Checked: now, Failures: tt.failures}
+		if got := s.next().Sub(now); got != tt.want {
+			t.Errorf("%d failures: got %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
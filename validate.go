@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"text/template"
+)
+
+// MisusePolicy selects when an App reports the mistakes of its definition,
+// like two commands with the same name or an invalid help template.
+type MisusePolicy int
+
+const (
+	// MisuseError leaves the check to Validate, for apps whose commands are
+	// only known at run time, like the ones loaded from plugins.
+	MisuseError MisusePolicy = iota
+	// MisusePanic panics when the app is run, so that developers see the
+	// mistakes at once.
+	MisusePanic
+)
+
+// Validate checks the definition of the app and of its commands: their
+// names, aliases, templates, positional arguments and the flags given in
+// Conflicts, Requires and Dangerous. All the mistakes found are returned as
+// Errors.
+func (a *App) Validate() error {
+	var (
+		errs  Errors
+		names = make(map[string]*Command)
+		def   *Command
+	)
+	if err := checkTemplate("help", a.Template); err != nil {
+		errs.Add(err)
+	}
+	cs := a.Commands
+	if a.Main != nil {
+		cs = append([]*Command{a.Main}, cs...)
+	}
+	for _, c := range cs {
+		if !c.Runnable() {
+			continue
+		}
+		if c.String() == "" {
+			errs.Add(fmt.Errorf("command without name"))
+			continue
+		}
+		for _, n := range append([]string{c.String()}, c.Alias...) {
+			if other, ok := names[n]; ok && other != c {
+				errs.Add(fmt.Errorf("%s: defined by the commands %s and %s", n, other, c))
+				continue
+			}
+			names[n] = c
+		}
+		if c.Default {
+			if def != nil {
+				errs.Add(fmt.Errorf("%s: second default command, after %s", c, def))
+			}
+			def = c
+		}
+		for _, err := range c.validate() {
+			errs.Add(fmt.Errorf("%s: %w", c, err))
+		}
+	}
+	var aliases []string
+	for n := range a.Aliases {
+		aliases = append(aliases, n)
+	}
+	sort.Strings(aliases)
+	for _, n := range aliases {
+		if _, ok := names[n]; ok {
+			errs.Add(fmt.Errorf("%s: alias hiding a command", n))
+		}
+		if _, err := Split(a.Aliases[n]); err != nil {
+			errs.Add(fmt.Errorf("alias %s: %w", n, err))
+		}
+	}
+	return errs.Err()
+}
+
+func (c *Command) validate() []error {
+	var errs []error
+	if err := checkTemplate(c.String(), c.Template); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkArgs(c.Args); err != nil {
+		errs = append(errs, err)
+	}
+	c.prepare()
+	known := func(what, name string) {
+		if c.Flag.Lookup(name) == nil {
+			errs = append(errs, fmt.Errorf("-%s: unknown flag in %s", name, what))
+		}
+	}
+	for _, group := range c.Conflicts {
+		for _, name := range group {
+			known("Conflicts", name)
+		}
+	}
+	var names []string
+	for name := range c.Requires {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		known("Requires", name)
+		for _, other := range c.Requires[name] {
+			known("Requires", other)
+		}
+	}
+	for _, name := range c.Dangerous {
+		known("Dangerous", name)
+	}
+	return errs
+}
+
+func checkTemplate(name, text string) error {
+	if text == "" {
+		return nil
+	}
+	_, err := template.New(name).Funcs(contextFuncs(context.Background())).Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid help template: %w", err)
+	}
+	return nil
+}
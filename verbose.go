@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"context"
+	"io"
+)
+
+type verbosityKey struct{}
+
+// Verbosity returns the number of times the global --verbose flag was given.
+func Verbosity(ctx context.Context) int {
+	n, _ := ctx.Value(verbosityKey{}).(int)
+	return n
+}
+
+func withVerbosity(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, verbosityKey{}, n)
+}
+
+// Verbose prints an informational message on w when the verbosity of ctx is
+// at least level.
+func Verbose(ctx context.Context, w io.Writer, level int, format string, args ...interface{}) {
+	if Verbosity(ctx) < level {
+		return
+	}
+	Message(ctx, w, Info, format, args...)
+}
+
+// Verbose prints an informational message on the standard error of the
+// command. See Verbose.
+func (c *Command) Verbose(level int, format string, args ...interface{}) {
+	Verbose(c.Context(), c.Stderr(), level, format, args...)
+}
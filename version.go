@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// VersionPolicy controls how an App handles --version and the version
+// command.
+type VersionPolicy struct {
+	// Combine makes --version behave like the version command, so that it can
+	// be followed by the flags of the version command, like
+	// --version -output json. Otherwise, the version is printed as soon as
+	// --version is seen and the other arguments are ignored.
+	Combine bool
+	// Strict makes the arguments given after the version command an error
+	// instead of ignoring them.
+	Strict bool
+}
+
+func isVersionFlag(arg string) bool {
+	return arg == "-v" || arg == "-version" || arg == "--version"
+}
+
+// version runs the builtin version command. It accepts the -output flag with
+// the text and json formats.
+func (a *App) version(ctx context.Context, args []string) error {
+	var (
+		format = "text"
+		fset   = flag.NewFlagSet("version", flag.ContinueOnError)
+	)
+	fset.SetOutput(io.Discard)
+	fset.Var(outputValue{&format}, "output", "")
+	fset.Var(outputValue{&format}, "o", "")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if a.Version.Strict && fset.NArg() > 0 {
+		return fmt.Errorf("version: unexpected argument %q", fset.Arg(0))
+	}
+	w := a.stdout()
+	switch format {
+	case "text":
+		printVersion(ctx, w)
+		return nil
+	case "json":
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(versionInfo(ctx))
+	default:
+		p, err := NewPrinter(w, format)
+		if err != nil {
+			return err
+		}
+		if err := p.Print(versionInfo(ctx)); err != nil {
+			return err
+		}
+		return p.Flush()
+	}
+}
@@ -0,0 +1,230 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SymlinkPolicy selects how a Walker handles symbolic links.
+type SymlinkPolicy int
+
+const (
+	// SymlinkReport gives the links to the walk function without following
+	// them.
+	SymlinkReport SymlinkPolicy = iota
+	// SymlinkFollow gives the targets of the links to the walk function and
+	// walks the directories they point to, except when it would loop.
+	SymlinkFollow
+	// SymlinkSkip ignores the links.
+	SymlinkSkip
+)
+
+// Walker walks directory trees with several workers reading directories
+// concurrently.
+type Walker struct {
+	// Jobs is the number of directories read at the same time. It defaults
+	// to Jobs(ctx).
+	Jobs     int
+	Symlinks SymlinkPolicy
+	// Progress, when set, is given the size of the regular files visited.
+	Progress *Progress
+}
+
+// Walk calls fn for root and for every file and directory under it. Unlike
+// filepath.WalkDir, fn is called concurrently, in no particular order, except
+// that a directory is always given before its entries. When fn returns
+// filepath.SkipDir for a directory, its entries are not walked.
+//
+// Walk does not stop at the first error: the errors of fn and the ones met
+// reading the directories are returned together as Errors. It stops when ctx
+// is done.
+func (w *Walker) Walk(ctx context.Context, root string, fn func(path string, e fs.DirEntry) error) error {
+	i, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	jobs := w.Jobs
+	if jobs <= 0 {
+		jobs = Jobs(ctx)
+	}
+	s := walkState{
+		walker: w,
+		fn:     fn,
+	}
+	s.cond = sync.NewCond(&s.mu)
+	s.visit(ctx, walkDir{}, root, statEntry{i})
+
+	var wg sync.WaitGroup
+	for j := 0; j < jobs; j++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	sort.Slice(s.errs, func(i, j int) bool {
+		return s.errs[i].Error() < s.errs[j].Error()
+	})
+	return s.errs.Err()
+}
+
+type walkDir struct {
+	path string
+	// parents are the directories leading to path, to detect the loops
+	// when following links.
+	parents []os.FileInfo
+}
+
+type walkState struct {
+	walker *Walker
+	fn     func(string, fs.DirEntry) error
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []walkDir
+	pending int
+	errs    Errors
+}
+
+func (s *walkState) work(ctx context.Context) {
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && s.pending > 0 {
+			s.cond.Wait()
+		}
+		if s.pending == 0 {
+			s.mu.Unlock()
+			return
+		}
+		dir := s.queue[len(s.queue)-1]
+		s.queue = s.queue[:len(s.queue)-1]
+		s.mu.Unlock()
+
+		if ctx.Err() == nil {
+			s.read(ctx, dir)
+		}
+
+		s.mu.Lock()
+		s.pending--
+		if s.pending == 0 {
+			s.cond.Broadcast()
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *walkState) read(ctx context.Context, dir walkDir) {
+	list, err := os.ReadDir(dir.path)
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	for _, e := range list {
+		if ctx.Err() != nil {
+			return
+		}
+		file := filepath.Join(dir.path, e.Name())
+		if e.Type()&fs.ModeSymlink != 0 {
+			switch s.walker.Symlinks {
+			case SymlinkSkip:
+				continue
+			case SymlinkFollow:
+				i, err := os.Stat(file)
+				if err != nil {
+					s.fail(err)
+					continue
+				}
+				if i.IsDir() && loops(dir, i) {
+					s.fail(fmt.Errorf("%s: symbolic link loop", file))
+					continue
+				}
+				e = statEntry{i}
+			}
+		}
+		s.visit(ctx, dir, file, e)
+	}
+}
+
+func (s *walkState) visit(ctx context.Context, parent walkDir, file string, e fs.DirEntry) {
+	err := s.fn(file, e)
+	if errors.Is(err, filepath.SkipDir) {
+		return
+	}
+	s.fail(err)
+	if p := s.walker.Progress; p != nil && e.Type().IsRegular() {
+		if i, err := e.Info(); err == nil {
+			p.Add(Size(i.Size()))
+		}
+	}
+	if !e.IsDir() {
+		return
+	}
+	dir := walkDir{path: file}
+	if s.walker.Symlinks == SymlinkFollow {
+		i, err := e.Info()
+		if err != nil {
+			s.fail(err)
+			return
+		}
+		dir.parents = append(parent.parents[:len(parent.parents):len(parent.parents)], i)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, dir)
+	s.pending++
+	s.cond.Signal()
+}
+
+func (s *walkState) fail(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errs.Add(err)
+}
+
+func loops(dir walkDir, i os.FileInfo) bool {
+	for _, p := range dir.parents {
+		if os.SameFile(p, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// statEntry is a fs.DirEntry made from the result of os.Stat.
+type statEntry struct {
+	info os.FileInfo
+}
+
+func (e statEntry) Name() string               { return e.info.Name() }
+func (e statEntry) IsDir() bool                { return e.info.IsDir() }
+func (e statEntry) Type() fs.FileMode          { return e.info.Mode().Type() }
+func (e statEntry) Info() (fs.FileInfo, error) { return e.info, nil }
+
+// walkOrder sorts files in the order of filepath.WalkDir.
+func walkOrder(files []string) {
+	sort.Slice(files, func(i, j int) bool {
+		a := strings.Split(filepath.ToSlash(files[i]), "/")
+		b := strings.Split(filepath.ToSlash(files[j]), "/")
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWalkerSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need privileges on windows")
+	}
+	root := t.TempDir()
+	for _, dir := range []string{"a/b", "c"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "c", "file"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"a/b/up":  "../..",
+		"a/to-c":  "../c",
+		"c/self":  ".",
+		"a/b/bad": "missing",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		policy SymlinkPolicy
+		want   []string
+		errs   []string
+	}{
+		{
+			policy: SymlinkSkip,
+			want:   []string{".", "a", "a/b", "c", "c/file"},
+		},
+		{
+			policy: SymlinkReport,
+			want:   []string{".", "a", "a/b", "a/b/bad", "a/b/up", "a/to-c", "c", "c/file", "c/self"},
+		},
+		{
+			policy: SymlinkFollow,
+			want:   []string{".", "a", "a/b", "a/to-c", "a/to-c/file", "c", "c/file"},
+			errs:   []string{"a/b/bad", "a/b/up: symbolic link loop", "a/to-c/self: symbolic link loop", "c/self: symbolic link loop"},
+		},
+	}
+	for _, tt := range tests {
+		var (
+			mu   sync.Mutex
+			got  []string
+			walk = Walker{Jobs: 2, Symlinks: tt.policy}
+		)
+		err := walk.Walk(context.Background(), root, func(file string, e fs.DirEntry) error {
+			rel, _ := filepath.Rel(root, file)
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, filepath.ToSlash(rel))
+			return nil
+		})
+		walkOrder(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("policy %d: got %q, want %q", tt.policy, got, tt.want)
+		}
+		var msg string
+		if err != nil {
+			msg = filepath.ToSlash(strings.ReplaceAll(err.Error(), root+string(filepath.Separator), ""))
+		}
+		for _, e := range tt.errs {
+			if !strings.Contains(msg, e) {
+				t.Errorf("policy %d: error %q does not report %q", tt.policy, msg, e)
+			}
+		}
+		if len(tt.errs) == 0 && err != nil {
+			t.Errorf("policy %d: unexpected error: %s", tt.policy, err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// WarningInfo describes a warning that an app can print. Users suppress it
+// with its ID, given to the global --suppress-warn flag or listed under the
+// warnings.suppress key of the configuration.
+type WarningInfo struct {
+	ID   string
+	Desc string
+}
+
+// builtinWarnings are the warnings printed by the package itself.
+var builtinWarnings = []WarningInfo{
+	{ID: HookFailedWarning, Desc: "a post-exec hook of the configuration failed"},
+	{ID: HistoryFailedWarning, Desc: "a command could not be recorded in the history"},
+}
+
+type suppressKey struct{}
+
+// Warn prints the warning id on w, unless it is suppressed. The ID is shown
+// with the message so that users know how to suppress it.
+func Warn(ctx context.Context, w io.Writer, id, format string, args ...interface{}) {
+	if Suppressed(ctx, id) {
+		return
+	}
+	Message(ctx, w, Warning, "%s [%s]", fmt.Sprintf(format, args...), id)
+}
+
+// Suppressed reports whether the warning id is suppressed.
+func Suppressed(ctx context.Context, id string) bool {
+	list, _ := ctx.Value(suppressKey{}).(Patterns)
+	for _, p := range list {
+		if ok, _ := filepath.Match(p, id); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Warn prints the warning id on the standard error of the command. See Warn.
+func (c *Command) Warn(id, format string, args ...interface{}) {
+	Warn(c.Context(), c.Stderr(), id, format, args...)
+}
+
+// withSuppressed adds to ctx the warnings suppressed with list and in the
+// configuration. When the app declares its warnings, the IDs given should be
+// known.
+func (a *App) withSuppressed(ctx context.Context, list Patterns) (context.Context, error) {
+	if v, ok := ConfigFrom(ctx).Lookup("warnings.suppress"); ok {
+		switch v := v.(type) {
+		case []string:
+			list = append(list, v...)
+		case string:
+			list = append(list, strings.Split(v, ",")...)
+		}
+	}
+	if len(list) == 0 {
+		return ctx, nil
+	}
+	if len(a.Warnings) > 0 {
+		var ids []string
+		for _, w := range a.warnings() {
+			ids = append(ids, w.ID)
+		}
+		for _, p := range list {
+			if strings.ContainsAny(p, "*?[") {
+				continue
+			}
+			if !containsString(ids, p) {
+				return ctx, choiceError("warning", p, ids)
+			}
+		}
+	}
+	return context.WithValue(ctx, suppressKey{}, list), nil
+}
+
+func (a *App) listWarnings(ctx context.Context, w io.Writer) {
+	tw := tabwriter.NewWriter(w, 4, 2, 2, ' ', 0)
+	for _, i := range a.warnings() {
+		state := "enabled"
+		if Suppressed(ctx, i.ID) {
+			state = "suppressed"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", i.ID, state, i.Desc)
+	}
+	tw.Flush()
+}
+
+func (a *App) warnings() []WarningInfo {
+	list := append([]WarningInfo{}, a.Warnings...)
+	return append(list, builtinWarnings...)
+}
+
+func containsString(list []string, str string) bool {
+	for _, s := range list {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}